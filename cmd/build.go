@@ -4,19 +4,28 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/kamil-koziol/restree/internal/envutil"
 	"github.com/kamil-koziol/restree/pkg/restree"
+	"github.com/kamil-koziol/restree/pkg/restree/export"
 )
 
 type BuildCmdFlags struct {
-	Output              io.WriteCloser
-	Body                string
+	Output io.WriteCloser
+	Body   string
+	// Export renders the request as a shell command, the .http format is used when nil
+	Export              export.Exporter
 	Directory           string
+	Env                 string
 	ExpandBodyVariables bool
+	ScriptTimeout       time.Duration
+	NoCache             bool
+	ScriptShell         string
 }
 
 func Build(base []string, args []string) int {
@@ -24,7 +33,7 @@ func Build(base []string, args []string) int {
 	buildCmd.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <filename>\n", strings.Join(base, " "))
 		fmt.Fprintf(os.Stderr, "\nPositional arguments:\n")
-		fmt.Fprintf(os.Stderr, "  filename\tPath to the .http file\n")
+		fmt.Fprintf(os.Stderr, "  filename\tPath to the .http file, optionally followed by #<name> or #<index> to select a request\n")
 		fmt.Fprintf(os.Stderr, "\nFlags:\n")
 		buildCmd.PrintDefaults()
 	}
@@ -39,9 +48,23 @@ func Build(base []string, args []string) int {
 		return err
 	})
 
+	buildCmd.Func("format", fmt.Sprintf("Output format, one of: http, %s (default http)", strings.Join(export.Names(), ", ")), func(s string) (err error) {
+		if s == "http" {
+			flags.Export = nil
+			return nil
+		}
+		flags.Export, err = export.New(s)
+		return err
+	})
 	buildCmd.StringVar(&flags.Body, "b", "", "Specify the input for the final .http body. Use a file path to write to a file, or '-' to use stdin")
+	SeedFlag(buildCmd)
+
 	buildCmd.StringVar(&flags.Directory, "D", "", "Specify the starting directory")
+	buildCmd.StringVar(&flags.Env, "env", "", "Select the environment profile loaded from _env/<env>.env files")
 	buildCmd.BoolVar(&flags.ExpandBodyVariables, "expand-body-variables", false, "Expand body variables")
+	buildCmd.DurationVar(&flags.ScriptTimeout, "script-timeout", 0, "Interrupt _before.sh scripts running longer than the duration, e.g. 30s (default no timeout)")
+	buildCmd.BoolVar(&flags.NoCache, "no-cache", false, "Run _before.sh scripts ignoring their cached output")
+	buildCmd.StringVar(&flags.ScriptShell, "script-shell", "", "Shell running _before.sh and _after.sh scripts without a shebang line (default /bin/sh)")
 
 	if err := buildCmd.Parse(args); err != nil {
 		fmt.Fprintln(os.Stderr, "unable to parse args")
@@ -54,34 +77,48 @@ func Build(base []string, args []string) int {
 		return 1
 	}
 
-	filePath := buildCmd.Arg(0)
+	filePath, request := restree.SplitTarget(buildCmd.Arg(0))
 
-	dir := ""
-	if flags.Directory == "" {
-		var err error
-		dir, err = os.Getwd()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: could not get current working directory: %s\n", err)
-			return 1
-		}
-	} else {
-		dir = flags.Directory
+	filePath, err := filepath.Abs(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
+		return 1
 	}
 
-	dir, err := filepath.Abs(dir)
+	dir, err := ResolveRoot(flags.Directory, filepath.Dir(filePath))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error: could not resolve the root directory: %s\n", err)
+		return 1
+	}
+
+	if err := ApplyConfig(buildCmd, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 		return 1
 	}
 
-	filePath, err = filepath.Abs(filePath)
+	variables := envutil.All()
+	captured, err := restree.LoadCapturedVariables(filepath.Join(dir, restree.CapturedVariablesFileName))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
+		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
+	maps.Copy(variables, captured)
+
+	// stdin is reserved for the body when it is read from it
+	var scriptStdin io.Reader = os.Stdin
+	if flags.Body == "-" {
+		scriptStdin = nil
+	}
 
-	httpFile, err := restree.RecursiveReadFS(os.DirFS(dir), dir, filePath, envutil.All(), restree.RecursiveReadOpts{
+	httpFile, err := restree.RecursiveReadFS(os.DirFS(dir), dir, filePath, variables, restree.RecursiveReadOpts{
 		ExpandBodyVariables: flags.ExpandBodyVariables,
+		Request:             request,
+		Env:                 flags.Env,
+		ScriptStdin:         scriptStdin,
+		ScriptTimeout:       flags.ScriptTimeout,
+		ScriptShell:         flags.ScriptShell,
+		ScriptCache:         NewScriptCache(flags.NoCache),
+		Captured:            captured,
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -109,6 +146,18 @@ func Build(base []string, args []string) int {
 			return 1
 		}
 		httpFile.Body = string(b)
+		httpFile.BodyFile = ""
+		httpFile.Parts = nil
+	}
+
+	if flags.Export != nil {
+		command, err := flags.Export(httpFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+		_, _ = fmt.Fprintln(flags.Output, command)
+		return 0
 	}
 
 	_, _ = fmt.Fprintln(flags.Output, httpFile.String())
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/restree"
+)
+
+func Cache(base []string, args []string) int {
+	cacheCmd := flag.NewFlagSet("cache", flag.ExitOnError)
+	cacheCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s <command>\n", strings.Join(base, " "))
+		fmt.Fprintf(os.Stderr, "\nCommands:\n")
+		fmt.Fprintf(os.Stderr, "  clear\tRemove the cached output of _before.sh scripts\n")
+		fmt.Fprintf(os.Stderr, "  dir\tPrint the cache directory\n")
+	}
+
+	if err := cacheCmd.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to parse args")
+		return 1
+	}
+
+	if cacheCmd.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Error: missing required <command> argument.")
+		cacheCmd.Usage()
+		return 1
+	}
+
+	dir, err := restree.DefaultScriptCacheDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to find the cache directory: %s\n", err)
+		return 1
+	}
+	cache := &restree.ScriptCache{Dir: dir}
+
+	switch cacheCmd.Arg(0) {
+	case "clear":
+		if err := cache.Clear(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to clear the cache: %s\n", err)
+			return 1
+		}
+	case "dir":
+		fmt.Println(cache.Dir)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", cacheCmd.Arg(0))
+		cacheCmd.Usage()
+		return 1
+	}
+
+	return 0
+}
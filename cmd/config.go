@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/kamil-koziol/restree/pkg/restree/config"
+)
+
+// ApplyConfig sets the flags not passed on the command line from the restree.toml file of the root
+//
+// Flags of the config that the subcommand does not define are ignored.
+func ApplyConfig(flagSet *flag.FlagSet, root string) error {
+	cfg, err := config.Load(root)
+	if err != nil {
+		return err
+	}
+
+	passed := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+
+	for name, values := range configFlags(cfg) {
+		if passed[name] || flagSet.Lookup(name) == nil {
+			continue
+		}
+		for _, value := range values {
+			if err := flagSet.Set(name, value); err != nil {
+				return fmt.Errorf("invalid %s value for -%s: %w", config.FileName, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// configFlags maps the config to the values of the flags it sets
+func configFlags(cfg *config.Config) map[string][]string {
+	flags := map[string][]string{}
+
+	setString := func(name string, value string) {
+		if value != "" {
+			flags[name] = []string{value}
+		}
+	}
+	setBool := func(name string, value bool) {
+		if value {
+			flags[name] = []string{strconv.FormatBool(value)}
+		}
+	}
+
+	setString("env", cfg.Env)
+	setBool("expand-body-variables", cfg.ExpandBodyVariables)
+	setBool("v", cfg.Verbose)
+	setBool("k", cfg.TLS.InsecureSkipVerify)
+	setString("cacert", cfg.TLS.CACert)
+	if cfg.HTTP.Timeout > 0 {
+		setString("timeout", cfg.HTTP.Timeout.String())
+	}
+	setString("proxy", cfg.HTTP.Proxy)
+	setString("script-shell", cfg.Scripts.Shell)
+	if cfg.Scripts.Timeout > 0 {
+		setString("script-timeout", cfg.Scripts.Timeout.String())
+	}
+	setBool("no-cache", cfg.Scripts.NoCache)
+	setString("format", cfg.Build.Format)
+	setString("reporter", cfg.Test.Reporter)
+	if len(cfg.Test.Ignore) > 0 {
+		flags["exclude"] = cfg.Test.Ignore
+	}
+
+	return flags
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kamil-koziol/restree/internal/envutil"
+	"github.com/kamil-koziol/restree/pkg/restree"
+)
+
+// processEnvironmentSource is the source of variables inherited from the process environment
+const processEnvironmentSource = "process environment"
+
+type ExplainCmdFlags struct {
+	Output              io.WriteCloser
+	Directory           string
+	Env                 string
+	ExpandBodyVariables bool
+	ScriptTimeout       time.Duration
+	NoCache             bool
+	ScriptShell         string
+	AllVariables        bool
+}
+
+func Explain(base []string, args []string) int {
+	explainCmd := flag.NewFlagSet("explain", flag.ExitOnError)
+	explainCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <filename>\n", strings.Join(base, " "))
+		fmt.Fprintf(os.Stderr, "\nPositional arguments:\n")
+		fmt.Fprintf(os.Stderr, "  filename\tPath to the .http file, optionally followed by #<name> or #<index> to select a request\n")
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		explainCmd.PrintDefaults()
+	}
+
+	flags := ExplainCmdFlags{
+		Output: os.Stdout,
+	}
+	defer flags.Output.Close() //nolint
+
+	explainCmd.Func("o", "Output file", func(s string) (err error) {
+		flags.Output, err = ResolveOutput(s)
+		return err
+	})
+
+	explainCmd.StringVar(&flags.Directory, "D", "", "Specify the starting directory")
+	explainCmd.StringVar(&flags.Env, "env", "", "Select the environment profile loaded from _env/<env>.env files")
+	explainCmd.BoolVar(&flags.ExpandBodyVariables, "expand-body-variables", false, "Expand body variables")
+	explainCmd.DurationVar(&flags.ScriptTimeout, "script-timeout", 0, "Interrupt _before.sh scripts running longer than the duration, e.g. 30s (default no timeout)")
+	explainCmd.BoolVar(&flags.NoCache, "no-cache", false, "Run _before.sh scripts ignoring their cached output")
+	explainCmd.StringVar(&flags.ScriptShell, "script-shell", "", "Shell running _before.sh and _after.sh scripts without a shebang line (default /bin/sh)")
+	explainCmd.BoolVar(&flags.AllVariables, "a", false, "Show all variables, including unused ones from the process environment")
+
+	if err := explainCmd.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to parse args")
+		return 1
+	}
+
+	if explainCmd.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Error: missing required <file> argument.")
+		flag.Usage()
+		return 1
+	}
+
+	filePath, request := restree.SplitTarget(explainCmd.Arg(0))
+
+	filePath, err := filepath.Abs(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
+		return 1
+	}
+
+	dir, err := ResolveRoot(flags.Directory, filepath.Dir(filePath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not resolve the root directory: %s\n", err)
+		return 1
+	}
+
+	if err := ApplyConfig(explainCmd, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	trace := &restree.Trace{}
+
+	variables := envutil.All()
+	trace.AddVariables(processEnvironmentSource, variables)
+
+	captured, err := restree.LoadCapturedVariables(filepath.Join(dir, restree.CapturedVariablesFileName))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	maps.Copy(variables, captured)
+	trace.AddVariables(restree.CapturedVariablesFileName, captured)
+
+	httpFile, err := restree.RecursiveReadFS(os.DirFS(dir), dir, filePath, variables, restree.RecursiveReadOpts{
+		ExpandBodyVariables: flags.ExpandBodyVariables,
+		Request:             request,
+		Env:                 flags.Env,
+		ScriptStdin:         os.Stdin,
+		ScriptTimeout:       flags.ScriptTimeout,
+		ScriptShell:         flags.ScriptShell,
+		ScriptCache:         NewScriptCache(flags.NoCache),
+		Captured:            captured,
+		Trace:               trace,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(flags.Output, 0, 4, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(w, "%s %s\n", httpFile.Method, httpFile.URL)
+
+	active, removed := trace.Headers()
+	for _, h := range active {
+		_, _ = fmt.Fprintf(w, "%s\t# %s%s\n", h.Header, h.Source, overrides(h.Overrides))
+	}
+
+	if len(removed) > 0 {
+		_, _ = fmt.Fprintln(w, "\nRemoved headers:")
+		for _, h := range removed {
+			_, _ = fmt.Fprintf(w, "  %s\t# %s, removed by %s\n", h.Header, h.Source, h.RemovedBy)
+		}
+	}
+
+	_, _ = fmt.Fprintln(w, "\nVariables:")
+	for _, v := range trace.Variables() {
+		if v.Source == processEnvironmentSource && !flags.AllVariables && !trace.Referenced(v.Name) {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %s = %s\t# %s%s\n", v.Name, v.Value, v.Source, overrides(v.Overrides))
+	}
+
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to write: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func overrides(sources []string) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	return ", overrides " + strings.Join(sources, ", ")
+}
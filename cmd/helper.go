@@ -1,8 +1,23 @@
 package cmd
 
 import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/restree"
+	restree_client "github.com/kamil-koziol/restree/pkg/restree/client"
 )
 
 func ResolveOutput(val string) (io.WriteCloser, error) {
@@ -12,3 +27,103 @@ func ResolveOutput(val string) (io.WriteCloser, error) {
 		return os.Create(val)
 	}
 }
+
+// stdin is shared by all confirmations, a reader per prompt would buffer the answers of the later prompts
+var stdin = bufio.NewReader(os.Stdin)
+
+// ConfirmRequest returns a confirmation function asking on stderr whether to send the request in the environment
+//
+// When yes is set the requests are confirmed without asking.
+func ConfirmRequest(env string, yes bool) func(req *httpparser.HTTPRequest) bool {
+	return func(req *httpparser.HTTPRequest) bool {
+		if yes {
+			return true
+		}
+
+		fmt.Fprintf(os.Stderr, "Send %s %s in %q environment? [y/N] ", req.Method, req.URL, env)
+		answer, _ := stdin.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// SeedFlag defines the -seed flag seeding the random generator of dynamic variables
+func SeedFlag(flagSet *flag.FlagSet) {
+	flagSet.Func("seed", "Seed the random generator of dynamic variables for reproducible output", func(s string) error {
+		seed, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		restree.SetDynamic(restree.NewDynamic(seed, time.Now))
+		return nil
+	})
+}
+
+// NewScriptCache returns the script cache in the default cache directory
+//
+// It returns nil, disabling the cache, when noCache is set or the cache directory is unknown.
+func NewScriptCache(noCache bool) *restree.ScriptCache {
+	if noCache {
+		return nil
+	}
+	dir, err := restree.DefaultScriptCacheDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: script cache disabled: %s\n", err)
+		return nil
+	}
+	return &restree.ScriptCache{Dir: dir}
+}
+
+// ResolveRoot returns the absolute root directory of the tree containing the start directory
+//
+// The directory is used when set, otherwise the root is discovered from start with [restree.FindRoot],
+// falling back to the current working directory.
+func ResolveRoot(directory string, start string) (string, error) {
+	if directory != "" {
+		return filepath.Abs(directory)
+	}
+	if root, ok := restree.FindRoot(start); ok {
+		return root, nil
+	}
+	return os.Getwd()
+}
+
+// NewClient returns the HTTP client configured by the TLS, proxy and timeout flags
+func NewClient(insecureSkipVerify bool, caCert string, proxy string, timeout time.Duration) (*restree_client.Client, error) {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: insecureSkipVerify,
+		},
+	}
+
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caCert)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	client := restree_client.New(transport)
+	client.Timeout = timeout
+	return client, nil
+}
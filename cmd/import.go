@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// importers are the subcommands of the import subcommand
+var importers = map[string]subcommand{
+	"curl": {
+		Run:         ImportCurl,
+		Description: "Convert a curl command line into a .http file",
+	},
+	"openapi": {
+		Run:         ImportOpenAPI,
+		Description: "Generate a request tree from an OpenAPI 3 specification",
+	},
+	"postman": {
+		Run:         ImportPostman,
+		Description: "Convert a Postman collection and its environments into a request tree",
+	},
+}
+
+// subcommand is a nested subcommand
+type subcommand struct {
+	Run         func([]string, []string) int
+	Description string
+}
+
+func Import(base []string, args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s <format> [flags] [args]\n", strings.Join(base, " "))
+		fmt.Fprintf(os.Stderr, "\nFormats:\n")
+		for _, name := range slices.Sorted(maps.Keys(importers)) {
+			fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, importers[name].Description)
+		}
+	}
+
+	if len(args) < 1 || args[0] == "-h" || args[0] == "--help" {
+		usage()
+		return 1
+	}
+
+	sub, ok := importers[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q\n\n", args[0])
+		usage()
+		return 1
+	}
+	return sub.Run(append(base, args[0]), args[1:])
+}
+
+// createImportFile creates the file written by an import, existing files are only replaced when force is set
+func createImportFile(path string, force bool) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return os.Stdout, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%s already exists, pass -f to overwrite it", path)
+	}
+	return f, err
+}
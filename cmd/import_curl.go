@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/restree"
+	"github.com/kamil-koziol/restree/pkg/restree/importer"
+)
+
+type ImportCurlCmdFlags struct {
+	Output         string
+	Directory      string
+	Env            string
+	Name           string
+	StripInherited bool
+	Variables      bool
+	Force          bool
+}
+
+func ImportCurl(base []string, args []string) int {
+	importCmd := flag.NewFlagSet("curl", flag.ExitOnError)
+	importCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [curl command]\n", strings.Join(base, " "))
+		fmt.Fprintf(os.Stderr, "\nPositional arguments:\n")
+		fmt.Fprintf(os.Stderr, "  curl command\tThe curl command as one quoted argument or as separate arguments after --, read from stdin when missing\n")
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		importCmd.PrintDefaults()
+	}
+
+	flags := ImportCurlCmdFlags{}
+	importCmd.StringVar(&flags.Output, "o", "", "Output .http file, stdout when not set")
+	importCmd.StringVar(&flags.Directory, "D", "", "Specify the root directory")
+	importCmd.StringVar(&flags.Env, "env", "", "Also use the variables of the _env/<env>.env files with --vars")
+	importCmd.StringVar(&flags.Name, "name", "", "Name of the request")
+	importCmd.BoolVar(&flags.StripInherited, "strip-inherited", false, "Remove headers already given by the _headers.http files of the parent directories")
+	importCmd.BoolVar(&flags.Variables, "vars", false, "Replace values of the variables of the tree with {{variable}} placeholders")
+	importCmd.BoolVar(&flags.Force, "f", false, "Overwrite the output file when it exists")
+
+	if err := importCmd.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to parse args")
+		return 1
+	}
+
+	words, err := curlWords(importCmd.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to read the curl command: %s\n", err)
+		return 1
+	}
+
+	req, warnings, err := importer.Curl(words)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to import the curl command: %s\n", err)
+		return 1
+	}
+	req.Name = flags.Name
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	// the request is placed in the directory of the output
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not get current working directory: %s\n", err)
+		return 1
+	}
+	outputDir := cwd
+	if flags.Output != "" && flags.Output != "-" {
+		output, err := filepath.Abs(flags.Output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
+			return 1
+		}
+		outputDir = filepath.Dir(output)
+	}
+	rebaseImportPaths(req, cwd, outputDir)
+
+	if flags.StripInherited || flags.Variables {
+		if err := applyTree(req, outputDir, flags); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+	}
+
+	out, err := createImportFile(flags.Output, flags.Force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	defer out.Close() //nolint:errcheck
+
+	if _, err := io.WriteString(out, req.Source()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to write: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// curlWords returns the words of the curl command given as arguments or on stdin
+func curlWords(args []string) ([]string, error) {
+	switch len(args) {
+	case 0:
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return importer.SplitShellWords(string(b))
+	case 1:
+		return importer.SplitShellWords(args[0])
+	default:
+		return args, nil
+	}
+}
+
+// applyTree strips the inherited headers and replaces the values of the variables of the tree containing dir
+func applyTree(req *httpparser.HTTPRequest, dir string, flags ImportCurlCmdFlags) error {
+	root, err := ResolveRoot(flags.Directory, dir)
+	if err != nil {
+		return fmt.Errorf("could not resolve the root directory: %w", err)
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("%s is outside of the root directory %s", dir, root)
+	}
+	fsys := os.DirFS(root)
+
+	if flags.StripInherited {
+		inherited, err := restree.InheritedHeaders(fsys, filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		importer.StripInherited(req, inherited)
+	}
+
+	if flags.Variables {
+		variables, err := restree.TreeVariables(fsys, filepath.ToSlash(rel), flags.Env)
+		if err != nil {
+			return err
+		}
+		captured, err := restree.LoadCapturedVariables(filepath.Join(root, restree.CapturedVariablesFileName))
+		if err != nil {
+			return err
+		}
+		maps.Copy(variables, captured)
+		importer.Templatize(req, variables)
+	}
+
+	return nil
+}
+
+// rebaseImportPaths makes the file paths relative to the directory of the command relative to the output directory
+func rebaseImportPaths(req *httpparser.HTTPRequest, from string, to string) {
+	rebase := func(p string) string {
+		if p == "" || strings.HasPrefix(p, "~") {
+			return p
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(from, p)
+		}
+		rel, err := filepath.Rel(to, p)
+		if err != nil {
+			return p
+		}
+		if !strings.HasPrefix(rel, "..") {
+			rel = "." + string(filepath.Separator) + rel
+		}
+		return filepath.ToSlash(rel)
+	}
+
+	req.BodyFile = rebase(req.BodyFile)
+	for i := range req.Parts {
+		req.Parts[i].File = rebase(req.Parts[i].File)
+	}
+}
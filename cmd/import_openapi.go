@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/restree/importer"
+)
+
+type ImportOpenAPICmdFlags struct {
+	Directory string
+	Verbose   bool
+}
+
+func ImportOpenAPI(base []string, args []string) int {
+	importCmd := flag.NewFlagSet("openapi", flag.ExitOnError)
+	importCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <spec>\n", strings.Join(base, " "))
+		fmt.Fprintf(os.Stderr, "\nPositional arguments:\n")
+		fmt.Fprintf(os.Stderr, "  spec\tPath to the OpenAPI 3 specification in JSON or YAML\n")
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		importCmd.PrintDefaults()
+	}
+
+	flags := ImportOpenAPICmdFlags{}
+	importCmd.StringVar(&flags.Directory, "D", ".", "Directory of the generated tree")
+	importCmd.BoolVar(&flags.Verbose, "v", false, "Also list the unchanged files")
+
+	if err := importCmd.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to parse args")
+		return 1
+	}
+
+	if importCmd.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Error: missing required <spec> argument.")
+		importCmd.Usage()
+		return 1
+	}
+
+	data, err := os.ReadFile(importCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to read the specification: %s\n", err)
+		return 1
+	}
+
+	files, warnings, err := importer.OpenAPI(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to import %s: %s\n", importCmd.Arg(0), err)
+		return 1
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	report, err := importer.WriteFiles(flags.Directory, files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	printWriteReport(report, flags.Verbose)
+
+	return 0
+}
+
+// printWriteReport lists the files written by an import
+func printWriteReport(report *importer.WriteReport, verbose bool) {
+	for _, path := range report.Created {
+		fmt.Fprintf(os.Stderr, "created   %s\n", path)
+	}
+	for _, path := range report.Updated {
+		fmt.Fprintf(os.Stderr, "updated   %s\n", path)
+	}
+	if verbose {
+		for _, path := range report.Unchanged {
+			fmt.Fprintf(os.Stderr, "unchanged %s\n", path)
+		}
+	}
+	for _, path := range report.Kept {
+		fmt.Fprintf(os.Stderr, "kept      %s (edited since it was generated)\n", path)
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/restree/importer"
+)
+
+type ImportPostmanCmdFlags struct {
+	Directory    string
+	Environments []string
+	Verbose      bool
+}
+
+func ImportPostman(base []string, args []string) int {
+	importCmd := flag.NewFlagSet("postman", flag.ExitOnError)
+	importCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <collection>\n", strings.Join(base, " "))
+		fmt.Fprintf(os.Stderr, "\nPositional arguments:\n")
+		fmt.Fprintf(os.Stderr, "  collection\tPath to the Postman collection exported in the v2.1 format\n")
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		importCmd.PrintDefaults()
+	}
+
+	flags := ImportPostmanCmdFlags{}
+	importCmd.StringVar(&flags.Directory, "D", ".", "Directory of the generated tree")
+	importCmd.Func("env", "Postman environment converted into an _env/<name>.env profile, can be repeated", func(s string) error {
+		flags.Environments = append(flags.Environments, s)
+		return nil
+	})
+	importCmd.BoolVar(&flags.Verbose, "v", false, "Also list the unchanged files")
+
+	if err := importCmd.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to parse args")
+		return 1
+	}
+
+	if importCmd.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Error: missing required <collection> argument.")
+		importCmd.Usage()
+		return 1
+	}
+
+	data, err := os.ReadFile(importCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to read the collection: %s\n", err)
+		return 1
+	}
+	files, warnings, err := importer.Postman(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to import %s: %s\n", importCmd.Arg(0), err)
+		return 1
+	}
+
+	for _, path := range flags.Environments {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to read the environment: %s\n", err)
+			return 1
+		}
+		profile, envWarnings, err := importer.PostmanEnvironment(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to import %s: %s\n", path, err)
+			return 1
+		}
+		files = append(files, profile)
+		warnings = append(warnings, envWarnings...)
+	}
+
+	report, err := importer.WriteFiles(flags.Directory, files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	printWriteReport(report, flags.Verbose)
+
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "\nMigration report, %d items were not fully converted:\n", len(warnings))
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "  - %s\n", w)
+		}
+	}
+
+	return 0
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/kamil-koziol/restree/pkg/restree"
@@ -50,6 +53,13 @@ func Init(base []string, args []string) int {
 
 	// Simple scaffold
 
+	// root marker
+	markerFilePath := filepath.Join(dir, restree.RootMarkerFileNames[0])
+	if err := os.WriteFile(markerFilePath, nil, 0o660); err != nil {
+		fmt.Fprintf(os.Stderr, "Error unable to create %s: %s\n", markerFilePath, err)
+		return 1
+	}
+
 	// .env
 	envFile := `host=http://localhost`
 
@@ -75,7 +85,9 @@ Connection: keep-alive
 	// before script
 	beforeScript := `#!/bin/sh
 
-cat .env`
+# Variables from .env are loaded automatically.
+# Print key=value lines to set variables computed at runtime, e.g.
+# echo "token=$(cat ~/.token)"`
 
 	beforeScriptFilePath := filepath.Join(dir, restree.BeforeScriptFileName)
 	if err := os.WriteFile(beforeScriptFilePath, []byte(beforeScript), 0o770); err != nil {
@@ -83,6 +95,13 @@ cat .env`
 		return 1
 	}
 
+	// captured values usually contain credentials
+	gitignorePath := filepath.Join(dir, ".gitignore")
+	if err := appendLine(gitignorePath, restree.CapturedVariablesFileName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error unable to update %s: %s\n", gitignorePath, err)
+		return 1
+	}
+
 	// simple http file
 	httpFile := `POST {{host}}/hello
 
@@ -98,3 +117,19 @@ cat .env`
 
 	return 0
 }
+
+// appendLine appends the line to the file unless it already contains it, the file is created when missing
+func appendLine(path string, line string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	content := string(data)
+	if slices.Contains(strings.Split(content, "\n"), line) {
+		return nil
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return os.WriteFile(path, []byte(content+line+"\n"), 0o644)
+}
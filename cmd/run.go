@@ -1,25 +1,33 @@
 package cmd
 
 import (
-	"crypto/tls"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/kamil-koziol/restree/internal/envutil"
 	"github.com/kamil-koziol/restree/pkg/restree"
-	restree_client "github.com/kamil-koziol/restree/pkg/restree/client"
 )
 
 type RunCmdFlags struct {
 	Output              io.WriteCloser
 	Directory           string
+	Env                 string
+	Yes                 bool
 	ExpandBodyVariables bool
+	ScriptTimeout       time.Duration
+	NoCache             bool
+	ScriptShell         string
 	InsecureSkipVerify  bool
+	CACert              string
+	Proxy               string
+	Timeout             time.Duration
 	Verbose             bool
 }
 
@@ -28,7 +36,7 @@ func Run(base []string, args []string) int {
 	runCmd.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <filename>\n", strings.Join(base, " "))
 		fmt.Fprintf(os.Stderr, "\nPositional arguments:\n")
-		fmt.Fprintf(os.Stderr, "  filename\tPath to the .http file\n")
+		fmt.Fprintf(os.Stderr, "  filename\tPath to the .http file, optionally followed by #<name> or #<index> to select a request\n")
 		fmt.Fprintf(os.Stderr, "\nFlags:\n")
 		runCmd.PrintDefaults()
 	}
@@ -43,9 +51,19 @@ func Run(base []string, args []string) int {
 		return err
 	})
 
+	SeedFlag(runCmd)
+
 	runCmd.StringVar(&flags.Directory, "D", "", "Specify the starting directory")
+	runCmd.StringVar(&flags.Env, "env", "", "Select the environment profile loaded from _env/<env>.env files")
+	runCmd.BoolVar(&flags.Yes, "y", false, "Send requests without asking for confirmation in environments that require it")
 	runCmd.BoolVar(&flags.ExpandBodyVariables, "expand-body-variables", false, "Expand body variables")
+	runCmd.DurationVar(&flags.ScriptTimeout, "script-timeout", 0, "Interrupt _before.sh scripts running longer than the duration, e.g. 30s (default no timeout)")
+	runCmd.BoolVar(&flags.NoCache, "no-cache", false, "Run _before.sh scripts ignoring their cached output")
+	runCmd.StringVar(&flags.ScriptShell, "script-shell", "", "Shell running _before.sh and _after.sh scripts without a shebang line (default /bin/sh)")
 	runCmd.BoolVar(&flags.InsecureSkipVerify, "k", false, "Allow insecure server connections")
+	runCmd.StringVar(&flags.CACert, "cacert", "", "Trust the CA certificates of the PEM file")
+	runCmd.StringVar(&flags.Proxy, "proxy", "", "Send requests through the proxy URL")
+	runCmd.DurationVar(&flags.Timeout, "timeout", 0, "Time limit of a request, e.g. 30s (default no limit)")
 	runCmd.BoolVar(&flags.Verbose, "v", false, "Increase the verbosity")
 
 	if err := runCmd.Parse(args); err != nil {
@@ -59,80 +77,119 @@ func Run(base []string, args []string) int {
 		return 1
 	}
 
-	filePath := runCmd.Arg(0)
-
-	dir := ""
-	if flags.Directory == "" {
-		var err error
-		dir, err = os.Getwd()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: could not get current working directory: %s\n", err)
-			return 1
-		}
-	} else {
-		dir = flags.Directory
-	}
+	filePath, request := restree.SplitTarget(runCmd.Arg(0))
 
-	dir, err := filepath.Abs(dir)
+	filePath, err := filepath.Abs(filePath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
 		return 1
 	}
 
-	filePath, err = filepath.Abs(filePath)
+	dir, err := ResolveRoot(flags.Directory, filepath.Dir(filePath))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error: could not resolve the root directory: %s\n", err)
 		return 1
 	}
 
-	httpFile, err := restree.RecursiveReadFS(os.DirFS(dir), dir, filePath, envutil.All(), restree.RecursiveReadOpts{
-		ExpandBodyVariables: flags.ExpandBodyVariables,
-	})
+	if err := ApplyConfig(runCmd, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	capturedPath := filepath.Join(dir, restree.CapturedVariablesFileName)
+	variables := envutil.All()
+	captured, err := restree.LoadCapturedVariables(capturedPath)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
+	maps.Copy(variables, captured)
 
-	client := restree_client.New(&http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: flags.InsecureSkipVerify,
-		},
-	})
-
-	var bodyReader io.Reader
-	if httpFile.Body != "" {
-		bodyReader = strings.NewReader(httpFile.Body)
+	opts := restree.RecursiveReadOpts{
+		ExpandBodyVariables: flags.ExpandBodyVariables,
+		Request:             request,
+		Env:                 flags.Env,
+		ScriptStdin:         os.Stdin,
+		ScriptTimeout:       flags.ScriptTimeout,
+		ScriptShell:         flags.ScriptShell,
+		ScriptCache:         NewScriptCache(flags.NoCache),
+		Captured:            captured,
+		Confirm:             ConfirmRequest(flags.Env, flags.Yes),
 	}
-	req, err := http.NewRequest(httpFile.Method, httpFile.URL, bodyReader)
+	httpFile, err := restree.RecursiveReadFS(os.DirFS(dir), dir, filePath, variables, opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "unable to create request: %s", err)
+		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
-	for header, value := range httpFile.Headers {
-		req.Header.Add(header, value)
-	}
 
-	resp, err := client.Do(req)
+	client, err := NewClient(flags.InsecureSkipVerify, flags.CACert, flags.Proxy, flags.Timeout)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error occured during request: %s", err)
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	result, err := restree.Execute(client, httpFile)
+	if err != nil && result == nil {
+		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
+	resp := result.Response
 
 	_, _ = fmt.Fprintf(os.Stderr, "%s %s %s\n", resp.Status, resp.Request.Method, resp.Request.URL.String())
 
 	if flags.Verbose {
-		for k, v := range resp.Header {
-			_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", k, strings.Join(v, ","))
+		for _, k := range slices.Sorted(maps.Keys(resp.Header)) {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", k, strings.Join(resp.Header[k], ","))
 		}
 	}
 
-	b, err := io.ReadAll(resp.Body)
+	_, _ = fmt.Fprintln(flags.Output, string(result.Body))
+
+	// the assertions are printed before the errors of the captures and the hooks
+	failed := printAssertions(os.Stderr, result)
+
+	// captures failed
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "unable to read response body: %s", err)
+		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
 
-	_, _ = fmt.Fprintln(flags.Output, string(b))
+	if err := restree.RunAfterScripts(dir, filePath, httpFile, result, variables, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if len(result.Captured) > 0 {
+		if err := restree.SaveCapturedVariables(capturedPath, result.Captured); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if flags.Verbose {
+			for _, c := range httpFile.Captures {
+				_, _ = fmt.Fprintf(os.Stderr, "captured %s\n", c.Name)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return 1
+	}
 
 	return 0
 }
+
+// printAssertions prints the assertion results and returns the number of failed ones
+func printAssertions(w io.Writer, result *restree.Result) int {
+	for _, a := range result.Assertions {
+		if a.Passed() {
+			_, _ = fmt.Fprintf(w, "PASS %s\n", a.Assertion)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "FAIL %s: %s\n", a.Assertion, a.Err)
+	}
+	failed := result.Failed()
+	if failed > 0 {
+		_, _ = fmt.Fprintf(w, "%d of %d assertions failed\n", failed, len(result.Assertions))
+	}
+	return failed
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamil-koziol/restree/internal/envutil"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/restree"
+	"github.com/kamil-koziol/restree/pkg/restree/report"
+)
+
+type TestCmdFlags struct {
+	Output              io.WriteCloser
+	Reporter            report.Reporter
+	Directory           string
+	Env                 string
+	Yes                 bool
+	Include             []string
+	Exclude             []string
+	Tags                []string
+	ExpandBodyVariables bool
+	ScriptTimeout       time.Duration
+	NoCache             bool
+	ScriptShell         string
+	InsecureSkipVerify  bool
+	CACert              string
+	Proxy               string
+	Timeout             time.Duration
+	Verbose             bool
+}
+
+// testCase is a single request executed by the test subcommand
+type testCase struct {
+	// ID identifies the request in the `path#index` format
+	ID       string
+	File     string
+	Name     string
+	Request  *httpparser.HTTPRequest
+	Result   *restree.Result
+	Duration time.Duration
+	// Err is set when the request could not be built or executed
+	Err error
+}
+
+func (tc *testCase) Passed() bool {
+	return tc.Err == nil && tc.Result.Failed() == 0
+}
+
+// Report converts the test case for [report.Reporter]
+func (tc *testCase) Report() report.Case {
+	c := report.Case{
+		ID:       tc.ID,
+		File:     tc.File,
+		Name:     tc.Name,
+		Duration: tc.Duration,
+	}
+	if tc.Request != nil {
+		c.Method = tc.Request.Method
+		c.URL = tc.Request.URL
+	}
+	if tc.Err != nil {
+		c.Error = tc.Err.Error()
+	}
+	if tc.Result != nil {
+		c.Status = tc.Result.Response.StatusCode
+		for _, a := range tc.Result.Assertions {
+			if !a.Passed() {
+				c.Failures = append(c.Failures, fmt.Sprintf("%s: %s", a.Assertion, a.Err))
+			}
+		}
+	}
+	return c
+}
+
+func Test(base []string, args []string) int {
+	testCmd := flag.NewFlagSet("test", flag.ExitOnError)
+	testCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [directory]\n", strings.Join(base, " "))
+		fmt.Fprintf(os.Stderr, "\nPositional arguments:\n")
+		fmt.Fprintf(os.Stderr, "  directory\tDirectory with .http files to run, defaults to the starting directory\n")
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		testCmd.PrintDefaults()
+	}
+
+	flags := TestCmdFlags{
+		Output:   os.Stdout,
+		Reporter: report.Text{},
+	}
+	defer flags.Output.Close() // nolint
+
+	testCmd.Func("o", "Output file", func(s string) (err error) {
+		flags.Output, err = ResolveOutput(s)
+		return err
+	})
+	testCmd.Func("reporter", fmt.Sprintf("Report format, one of: %s (default text)", strings.Join(report.Names(), ", ")), func(s string) (err error) {
+		flags.Reporter, err = report.New(s)
+		return err
+	})
+	testCmd.Func("include", "Only run files matching the glob pattern (repeatable)", func(s string) error {
+		flags.Include = append(flags.Include, s)
+		return nil
+	})
+	testCmd.Func("exclude", "Skip files matching the glob pattern (repeatable)", func(s string) error {
+		flags.Exclude = append(flags.Exclude, s)
+		return nil
+	})
+	testCmd.Func("tag", "Only run requests with the tag (repeatable)", func(s string) error {
+		flags.Tags = append(flags.Tags, s)
+		return nil
+	})
+
+	SeedFlag(testCmd)
+
+	testCmd.StringVar(&flags.Directory, "D", "", "Specify the starting directory")
+	testCmd.StringVar(&flags.Env, "env", "", "Select the environment profile loaded from _env/<env>.env files")
+	testCmd.BoolVar(&flags.Yes, "y", false, "Send requests without asking for confirmation in environments that require it")
+	testCmd.BoolVar(&flags.ExpandBodyVariables, "expand-body-variables", false, "Expand body variables")
+	testCmd.DurationVar(&flags.ScriptTimeout, "script-timeout", 0, "Interrupt _before.sh scripts running longer than the duration, e.g. 30s (default no timeout)")
+	testCmd.BoolVar(&flags.NoCache, "no-cache", false, "Run _before.sh scripts ignoring their cached output")
+	testCmd.StringVar(&flags.ScriptShell, "script-shell", "", "Shell running _before.sh and _after.sh scripts without a shebang line (default /bin/sh)")
+	testCmd.BoolVar(&flags.InsecureSkipVerify, "k", false, "Allow insecure server connections")
+	testCmd.StringVar(&flags.CACert, "cacert", "", "Trust the CA certificates of the PEM file")
+	testCmd.StringVar(&flags.Proxy, "proxy", "", "Send requests through the proxy URL")
+	testCmd.DurationVar(&flags.Timeout, "timeout", 0, "Time limit of a request, e.g. 30s (default no limit)")
+	testCmd.BoolVar(&flags.Verbose, "v", false, "Increase the verbosity")
+
+	if err := testCmd.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, "unable to parse args")
+		return 1
+	}
+
+	target, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not get current working directory: %s\n", err)
+		return 1
+	}
+	if testCmd.NArg() > 0 {
+		target, err = filepath.Abs(testCmd.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error with file abs path: %s\n", err)
+			return 1
+		}
+	}
+
+	dir, err := ResolveRoot(flags.Directory, target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not resolve the root directory: %s\n", err)
+		return 1
+	}
+
+	if err := ApplyConfig(testCmd, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || !filepath.IsLocal(rel) {
+		fmt.Fprintf(os.Stderr, "Error: %s is outside of the root directory %s\n", target, dir)
+		return 1
+	}
+	walkDir := filepath.ToSlash(rel)
+
+	fsys := os.DirFS(dir)
+	files, err := restree.FindHTTPFiles(fsys, walkDir, flags.Include, flags.Exclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to find .http files: %s\n", err)
+		return 1
+	}
+
+	capturedPath := filepath.Join(dir, restree.CapturedVariablesFileName)
+	variables := envutil.All()
+	captured, err := restree.LoadCapturedVariables(capturedPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	maps.Copy(variables, captured)
+
+	client, err := NewClient(flags.InsecureSkipVerify, flags.CACert, flags.Proxy, flags.Timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	cases := []*testCase{}
+	start := time.Now()
+
+	for _, file := range files {
+		requests, err := readRequests(fsys, file)
+		if err != nil {
+			tc := &testCase{ID: file, File: file, Err: err}
+			cases = append(cases, tc)
+			printTestCase(os.Stderr, tc, flags.Verbose)
+			continue
+		}
+
+		for i, req := range requests {
+			if len(flags.Tags) > 0 && !slices.ContainsFunc(req.Tags, func(tag string) bool { return slices.Contains(flags.Tags, tag) }) {
+				continue
+			}
+
+			tc := &testCase{
+				ID:   fmt.Sprintf("%s#%d", file, i+1),
+				File: file,
+				Name: req.Name,
+			}
+			cases = append(cases, tc)
+
+			tc.Request, tc.Result, tc.Err = runTestCase(client, fsys, dir, file, i+1, maps.Clone(variables), captured, flags)
+			if tc.Result != nil {
+				tc.Duration = tc.Result.Duration
+				maps.Copy(variables, tc.Result.Captured)
+				maps.Copy(captured, tc.Result.Captured)
+				if err := restree.SaveCapturedVariables(capturedPath, tc.Result.Captured); err != nil && tc.Err == nil {
+					tc.Err = err
+				}
+			}
+
+			printTestCase(os.Stderr, tc, flags.Verbose)
+		}
+	}
+
+	duration := time.Since(start)
+
+	reportCases := make([]report.Case, 0, len(cases))
+	for _, tc := range cases {
+		reportCases = append(reportCases, tc.Report())
+	}
+	if err := flags.Reporter.Report(flags.Output, reportCases, duration); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unable to write report: %s\n", err)
+		return 1
+	}
+
+	if report.Failed(reportCases) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// readRequests reads the unexpanded requests of the file
+func readRequests(fsys fs.FS, file string) ([]*httpparser.HTTPRequest, error) {
+	f, err := fsys.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	requests, err := httpparser.ParseAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return requests, nil
+}
+
+func runTestCase(client restree.Doer, fsys fs.FS, dir string, file string, index int, variables restree.Variables, captured restree.Variables, flags TestCmdFlags) (*httpparser.HTTPRequest, *restree.Result, error) {
+	target := filepath.Join(dir, filepath.FromSlash(file))
+	opts := restree.RecursiveReadOpts{
+		ExpandBodyVariables: flags.ExpandBodyVariables,
+		Request:             strconv.Itoa(index),
+		Env:                 flags.Env,
+		ScriptStdin:         os.Stdin,
+		ScriptTimeout:       flags.ScriptTimeout,
+		ScriptShell:         flags.ScriptShell,
+		ScriptCache:         NewScriptCache(flags.NoCache),
+		Confirm:             ConfirmRequest(flags.Env, flags.Yes),
+		Captured:            maps.Clone(captured),
+	}
+	httpFile, err := restree.RecursiveReadFS(fsys, dir, target, variables, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := restree.Execute(client, httpFile)
+	if err != nil {
+		return httpFile, result, err
+	}
+
+	err = restree.RunAfterScripts(dir, target, httpFile, result, variables, opts)
+	return httpFile, result, err
+}
+
+func printTestCase(w io.Writer, tc *testCase, verbose bool) {
+	name := tc.ID
+	if tc.Name != "" {
+		name = fmt.Sprintf("%s (%s)", tc.ID, tc.Name)
+	}
+
+	status := "PASS"
+	if !tc.Passed() {
+		status = "FAIL"
+	}
+	_, _ = fmt.Fprintf(w, "%s %s %s\n", status, name, tc.Duration.Round(time.Millisecond))
+
+	if tc.Result != nil {
+		for _, a := range tc.Result.Assertions {
+			if !a.Passed() {
+				_, _ = fmt.Fprintf(w, "    FAIL %s: %s\n", a.Assertion, a.Err)
+			} else if verbose {
+				_, _ = fmt.Fprintf(w, "    PASS %s\n", a.Assertion)
+			}
+		}
+	}
+	// the errors of the captures and the hooks come after the assertions
+	if tc.Err != nil {
+		_, _ = fmt.Fprintf(w, "    %s\n", tc.Err)
+	}
+}
@@ -18,6 +18,18 @@ var subcommands = map[string]Subcommand{
 		Run:         cmd.Build,
 		Description: "Recursively build http file",
 	},
+	"cache": {
+		Run:         cmd.Cache,
+		Description: "Manage the cached output of _before.sh scripts",
+	},
+	"explain": {
+		Run:         cmd.Explain,
+		Description: "Show where every header and variable of a request came from",
+	},
+	"import": {
+		Run:         cmd.Import,
+		Description: "Import requests from curl commands, OpenAPI specifications or Postman collections",
+	},
 	"init": {
 		Run:         cmd.Init,
 		Description: "Simple restree starter",
@@ -26,6 +38,10 @@ var subcommands = map[string]Subcommand{
 		Run:         cmd.Run,
 		Description: "Run http file",
 	},
+	"test": {
+		Run:         cmd.Test,
+		Description: "Run all http files in a directory as a test suite",
+	},
 }
 
 func main() {
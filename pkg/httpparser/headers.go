@@ -0,0 +1,188 @@
+package httpparser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HeaderOp is the way the header is merged with the inherited headers, see [HTTPHeaders.Merge]
+type HeaderOp int
+
+const (
+	// HeaderSet replaces the inherited values, written as `Name: Value`
+	HeaderSet HeaderOp = iota
+	// HeaderAppend adds the value to the inherited values, written as `+Name: Value`
+	HeaderAppend
+	// HeaderUnset removes the inherited values, written as `-Name`
+	HeaderUnset
+)
+
+// HTTPHeader is a single header line
+type HTTPHeader struct {
+	Name  string
+	Value string
+	Op    HeaderOp
+	// File is the path of the file the header was read from, empty when unknown
+	File string
+	// Line is the 1-based line number in the parsed file, 0 when unknown
+	Line int
+}
+
+func (h HTTPHeader) String() string {
+	switch h.Op {
+	case HeaderAppend:
+		return fmt.Sprintf("+%s: %s", h.Name, h.Value)
+	case HeaderUnset:
+		return fmt.Sprintf("-%s", h.Name)
+	default:
+		return fmt.Sprintf("%s: %s", h.Name, h.Value)
+	}
+}
+
+// HTTPHeaders is an ordered collection of headers
+//
+// Header names are case-insensitive and may repeat, e.g. multiple Accept or Cookie headers.
+type HTTPHeaders []HTTPHeader
+
+// Get returns the first value of the header and reports whether it was found
+func (h HTTPHeaders) Get(name string) (string, bool) {
+	for _, header := range h {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value, true
+		}
+	}
+	return "", false
+}
+
+// Values returns all values of the header in order
+func (h HTTPHeaders) Values(name string) []string {
+	values := []string{}
+	for _, header := range h {
+		if strings.EqualFold(header.Name, name) {
+			values = append(values, header.Value)
+		}
+	}
+	return values
+}
+
+// Has reports whether the header is present
+func (h HTTPHeaders) Has(name string) bool {
+	_, ok := h.Get(name)
+	return ok
+}
+
+// Add appends the header keeping existing values
+func (h *HTTPHeaders) Add(name string, value string) {
+	*h = append(*h, HTTPHeader{Name: name, Value: value})
+}
+
+// Set replaces all values of the header with the value
+//
+// The header keeps the position of its first occurrence, new headers are appended.
+func (h *HTTPHeaders) Set(name string, value string) {
+	for i, header := range *h {
+		if strings.EqualFold(header.Name, name) {
+			(*h)[i] = HTTPHeader{Name: name, Value: value}
+			*h = append((*h)[:i+1], (*h)[i+1:].without(name)...)
+			return
+		}
+	}
+	h.Add(name, value)
+}
+
+// Del removes all values of the header
+func (h *HTTPHeaders) Del(name string) {
+	*h = h.without(name)
+}
+
+// Merge returns the headers overridden by other, the closer level of the tree
+//
+// The headers of other are applied in order according to their [HeaderOp]:
+//
+//   - HeaderSet replaces all inherited values of the header at the position of its first occurrence,
+//     repeated headers in other are kept together
+//   - HeaderAppend adds the value after the inherited values
+//   - HeaderUnset removes all inherited values
+//
+// The returned headers are plain [HeaderSet] headers keeping their File and Line.
+func (h HTTPHeaders) Merge(other HTTPHeaders) HTTPHeaders {
+	result, _ := h.MergeChanges(other)
+	return result
+}
+
+// HeaderChange is an inherited header replaced or removed by a merge
+type HeaderChange struct {
+	// Header is the inherited header
+	Header HTTPHeader
+	// By is the header replacing it, or the [HeaderUnset] header removing it
+	By HTTPHeader
+}
+
+// MergeChanges is [HTTPHeaders.Merge] also returning the inherited headers replaced or removed by other
+func (h HTTPHeaders) MergeChanges(other HTTPHeaders) (HTTPHeaders, []HeaderChange) {
+	result := HTTPHeaders{}
+	for _, header := range h {
+		if header.Op != HeaderUnset {
+			header.Op = HeaderSet
+			result = append(result, header)
+		}
+	}
+
+	changes := []HeaderChange{}
+	replaced := map[string]bool{}
+	for _, o := range other {
+		key := strings.ToLower(o.Name)
+		header := o
+		header.Op = HeaderSet
+
+		if o.Op == HeaderAppend || (o.Op == HeaderSet && replaced[key]) {
+			result.insertAfter(header)
+			continue
+		}
+
+		replaced[key] = true
+		at := -1
+		for i, r := range result {
+			if strings.EqualFold(r.Name, o.Name) {
+				changes = append(changes, HeaderChange{Header: r, By: o})
+				if at == -1 {
+					at = i
+				}
+			}
+		}
+		result = result.without(o.Name)
+
+		switch {
+		case o.Op == HeaderUnset:
+		case at == -1:
+			result = append(result, header)
+		default:
+			// the header keeps the position of the first inherited value
+			result = slices.Insert(result, at, header)
+		}
+	}
+
+	return result, changes
+}
+
+// insertAfter inserts the header after the last occurrence of its name or appends it
+func (h *HTTPHeaders) insertAfter(header HTTPHeader) {
+	for i := len(*h) - 1; i >= 0; i-- {
+		if strings.EqualFold((*h)[i].Name, header.Name) {
+			*h = append((*h)[:i+1], append(HTTPHeaders{header}, (*h)[i+1:]...)...)
+			return
+		}
+	}
+	*h = append(*h, header)
+}
+
+func (h HTTPHeaders) without(name string) HTTPHeaders {
+	result := HTTPHeaders{}
+	for _, header := range h {
+		if !strings.EqualFold(header.Name, name) {
+			result = append(result, header)
+		}
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package httpparser
+
+import (
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func validHTTPHeaders() HTTPHeaders {
+	return HTTPHeaders{
+		{Name: "Accept", Value: "text/html"},
+		{Name: "Content-Type", Value: "application/json"},
+		{Name: "accept", Value: "application/json"},
+	}
+}
+
+func TestHTTPHeadersGet(t *testing.T) {
+	h := validHTTPHeaders()
+
+	v, ok := h.Get("ACCEPT")
+	assert.Eq(t, true, ok)
+	assert.Eq(t, "text/html", v)
+
+	values := h.Values("Accept")
+	assert.Eq(t, 2, len(values))
+	assert.Eq(t, "application/json", values[1])
+
+	_, ok = h.Get("Authorization")
+	assert.Eq(t, false, ok)
+}
+
+func TestHTTPHeadersSet(t *testing.T) {
+	h := validHTTPHeaders()
+	h.Set("Accept", "*/*")
+	assert.Eq(t, 2, len(h))
+	assert.Eq(t, HTTPHeader{Name: "Accept", Value: "*/*"}, h[0])
+	assert.Eq(t, "Content-Type", h[1].Name)
+
+	h.Set("Authorization", "token")
+	assert.Eq(t, 3, len(h))
+	assert.Eq(t, "Authorization", h[2].Name)
+
+	h.Del("content-type")
+	assert.Eq(t, 2, len(h))
+	assert.Eq(t, false, h.Has("Content-Type"))
+}
+
+func TestHTTPHeadersMerge(t *testing.T) {
+	h := validHTTPHeaders().Merge(HTTPHeaders{
+		{Name: "X-Forwarded-For", Value: "a"},
+		{Name: "ACCEPT", Value: "text/csv"},
+		{Name: "X-Forwarded-For", Value: "b"},
+	})
+
+	expected := HTTPHeaders{
+		{Name: "ACCEPT", Value: "text/csv"},
+		{Name: "Content-Type", Value: "application/json"},
+		{Name: "X-Forwarded-For", Value: "a"},
+		{Name: "X-Forwarded-For", Value: "b"},
+	}
+	assert.Eq(t, len(expected), len(h))
+	for i := range expected {
+		assert.Eq(t, expected[i], h[i])
+	}
+}
+
+func TestHTTPHeadersMergeOps(t *testing.T) {
+	h := validHTTPHeaders().Merge(HTTPHeaders{
+		{Name: "Content-Type", Op: HeaderUnset},
+		{Name: "Accept", Value: "text/csv", Op: HeaderAppend},
+		{Name: "Authorization", Value: "token", Op: HeaderAppend},
+	})
+
+	expected := HTTPHeaders{
+		{Name: "Accept", Value: "text/html"},
+		{Name: "accept", Value: "application/json"},
+		{Name: "Accept", Value: "text/csv"},
+		{Name: "Authorization", Value: "token"},
+	}
+	assert.Eq(t, len(expected), len(h))
+	for i := range expected {
+		assert.Eq(t, expected[i], h[i])
+	}
+}
+
+func TestHTTPHeadersMergeChanges(t *testing.T) {
+	inherited := HTTPHeaders{
+		{Name: "Accept", Value: "text/html", File: "_headers.http", Line: 1},
+		{Name: "Authorization", Value: "token", File: "_headers.http", Line: 2},
+	}
+	h, changes := inherited.MergeChanges(HTTPHeaders{
+		{Name: "accept", Value: "text/csv", File: "get.http", Line: 2},
+		{Name: "Authorization", Op: HeaderUnset, File: "get.http", Line: 3},
+	})
+
+	assert.Eq(t, 1, len(h))
+	assert.Eq(t, "get.http", h[0].File)
+	assert.Eq(t, 2, h[0].Line)
+
+	assert.Eq(t, 2, len(changes))
+	assert.Eq(t, 1, changes[0].Header.Line)
+	assert.Eq(t, 2, changes[0].By.Line)
+	assert.Eq(t, "Authorization", changes[1].Header.Name)
+	assert.Eq(t, HeaderUnset, changes[1].By.Op)
+}
@@ -4,14 +4,65 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 type HTTPRequest struct {
+	// Name is the optional name given after the ### separator
+	Name    string
 	Method  string
 	URL     string
 	Headers HTTPHeaders
 	Body    string
+	// BodyFile is the path of the file with the body, declared with `< path` as the only body line.
+	// The path is relative to the .http file.
+	BodyFile string
+	// ExpandBodyFile is set when the body file should be expanded with variables, declared with `<@ path`
+	ExpandBodyFile bool
+	// Parts are the parts of a multipart/form-data body, see [Part]
+	Parts []Part
+	// Captures are the values extracted from the response, declared with @capture
+	Captures []Capture
+	// Assertions are the expectations on the response, declared with @assert
+	Assertions []Assertion
+	// Tags are used to filter requests in test runs, declared with @tag
+	Tags []string
+}
+
+// Part is a single part of a multipart/form-data body
+//
+// Parts are declared in the body when the Content-Type header is multipart/form-data without a boundary.
+// Every part starts with a `--- <name> [filename=<filename>] [type=<content type>]` line followed by its value
+// or a `< path` file reference relative to the .http file.
+//
+//	--- description
+//	My avatar
+//	--- avatar filename="me.png" type=image/png
+//	< ./me.png
+type Part struct {
+	Name        string
+	Filename    string
+	ContentType string
+	// Value is the content of a text part
+	Value string
+	// File is the path of the file with the content
+	File string
+}
+
+// Capture describes a value extracted from the response into a variable
+//
+// Source is one of:
+//
+//	$.json.path    value from the JSON response body
+//	header <Name>  value of the response header
+//	status         response status code
+//	body           whole response body
+type Capture struct {
+	Name   string
+	Source string
 }
 
 func (req *HTTPRequest) String() string {
@@ -25,8 +76,8 @@ func (req *HTTPRequest) String() string {
 	}
 
 	// Headers
-	for header, value := range req.Headers {
-		s += fmt.Sprintf("%s: %s\n", header, value)
+	for _, header := range req.Headers {
+		s += header.String() + "\n"
 	}
 
 	// Body
@@ -37,32 +88,129 @@ func (req *HTTPRequest) String() string {
 	return s
 }
 
-type HTTPHeaders map[string]string
+// Parse parses .http file and returns the first request in it
+//
+// See [ParseAll] for the file structure.
+func Parse(body io.Reader) (*HTTPRequest, error) {
+	reqs, err := ParseAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return reqs[0], nil
+}
 
-// Parse parses .http file
+// ParseAll parses .http file that may contain multiple requests
 // .http file structure
 //
+// ### <optional name>
 // <HTTP_METHOD> <URL>
 // <Header-Name>: <Header-Value>
 // <Header-Name>: <Header-Value>
+// @capture <name> = <source>
+// @assert <subject> <operator> [expected]
+// @tag <name>[, <name>...]
 // ...
 //
-// <optional body in JSON, plain text, or form format>
-func Parse(body io.Reader) (*HTTPRequest, error) {
+// <optional body in JSON, plain text, or form format, or a `< path` / `<@ path` file reference>
+//
+// ### <optional name>
+// <HTTP_METHOD> <URL>
+// ...
+//
+// The leading ### separator is optional for the first request.
+// Directives starting with @ may appear before the request line or between the headers.
+func ParseAll(body io.Reader) ([]*HTTPRequest, error) {
 	scanner := bufio.NewScanner(body)
 
-	req := &HTTPRequest{
-		Headers: make(HTTPHeaders),
-	}
-
-	state := "start"
-	bodyLines := []string{}
+	reqs := []*HTTPRequest{}
+	name := ""
+	lines := []string{}
+	firstLine := 1
 	scannedLines := 0
 
+	flush := func() error {
+		if isBlank(lines) {
+			return nil
+		}
+		req, err := parseRequest(lines, firstLine)
+		if err != nil {
+			return err
+		}
+		req.Name = name
+		reqs = append(reqs, req)
+		return nil
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 		scannedLines += 1
 
+		if sepName, ok := cutSeparator(line); ok {
+			// empty lines before a separator belong to it, not to the body
+			for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+				lines = lines[:len(lines)-1]
+			}
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			name = sepName
+			lines = []string{}
+			firstLine = scannedLines + 1
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if scannedLines == 0 {
+		return nil, fmt.Errorf("cannot parse empty")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no requests found")
+	}
+
+	return reqs, nil
+}
+
+// Select returns the request matching the selector
+//
+// The selector is either a 1-based index of the request in the file or its name.
+// Names are matched case-insensitively with spaces and dashes being equivalent,
+// so "create-user" selects "### create user".
+func Select(reqs []*HTTPRequest, selector string) (*HTTPRequest, error) {
+	if idx, err := strconv.Atoi(selector); err == nil {
+		if idx < 1 || idx > len(reqs) {
+			return nil, fmt.Errorf("request index %d out of range [1, %d]", idx, len(reqs))
+		}
+		return reqs[idx-1], nil
+	}
+
+	for _, req := range reqs {
+		if req.Name != "" && slugify(req.Name) == slugify(selector) {
+			return req, nil
+		}
+	}
+
+	return nil, fmt.Errorf("request %q not found", selector)
+}
+
+// parseRequest parses lines of a single request starting at firstLine of the file
+func parseRequest(lines []string, firstLine int) (*HTTPRequest, error) {
+	req := &HTTPRequest{
+		Headers: HTTPHeaders{},
+	}
+
+	state := "start"
+	bodyLines := []string{}
+
+	for i, line := range lines {
 		switch state {
 		case "start":
 			// skip comments
@@ -75,10 +223,17 @@ func Parse(body io.Reader) (*HTTPRequest, error) {
 				continue
 			}
 
+			if strings.HasPrefix(line, "@") {
+				if err := parseDirective(req, line); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
 			parts := strings.Fields(line)
 
-			if !isHTTPMethod(parts[0]) {
-				return nil, fmt.Errorf("invalid HTTP method: %s", req.Method)
+			if !IsHTTPMethod(parts[0]) || len(parts) < 2 {
+				return nil, fmt.Errorf("invalid request line: %q", line)
 			}
 			req.Method = parts[0]
 			req.URL = parts[1]
@@ -95,44 +250,77 @@ func Parse(body io.Reader) (*HTTPRequest, error) {
 				continue
 			}
 
-			colonIdx := strings.Index(line, ":")
-			if colonIdx == -1 {
-				return nil, fmt.Errorf("invalid line: %q", line)
+			if strings.HasPrefix(line, "@") {
+				if err := parseDirective(req, line); err != nil {
+					return nil, err
+				}
+				continue
 			}
 
-			key := strings.TrimSpace(line[:colonIdx])
-			value := strings.TrimSpace(line[colonIdx+1:])
-			req.Headers[key] = value
+			header, err := parseHeaderLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid line: %q", line)
+			}
+			header.Line = firstLine + i
+			req.Headers = append(req.Headers, header)
 		case "body":
 			bodyLines = append(bodyLines, line)
 		}
 	}
 
-	if scannedLines == 0 {
-		return nil, fmt.Errorf("cannot parse empty")
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if state == "start" {
+		return nil, fmt.Errorf("missing request line")
 	}
 
 	req.Body = strings.Join(bodyLines, "\n")
 
+	if err := req.ParseMultipart(req.Headers); err != nil {
+		return nil, err
+	}
+	if path, expand, ok := cutBodyFile(req.Body); ok && len(req.Parts) == 0 {
+		req.Body = ""
+		req.BodyFile = path
+		req.ExpandBodyFile = expand
+	}
+
 	return req, nil
 }
 
+// ParseMultipart parses the body into parts when the headers declare multipart/form-data without a boundary
+//
+// The headers are the ones the request is sent with, e.g. merged with the headers inherited from the tree.
+// Bodies not starting with a part are kept.
+func (req *HTTPRequest) ParseMultipart(headers HTTPHeaders) error {
+	if len(req.Parts) > 0 || !isMultipart(headers) || !strings.HasPrefix(strings.TrimSpace(req.Body), partPrefix) {
+		return nil
+	}
+	parts, err := parseParts(strings.Split(req.Body, "\n"))
+	if err != nil {
+		return err
+	}
+	req.Parts = parts
+	req.Body = ""
+	return nil
+}
+
 // ParseHeadersFile parses .http file that contains only headers
 // .http file structure (headers only)
 //
 // <Header-Name>: <Header-Value>
-// <Header-Name>: <Header-Value>
+// +<Header-Name>: <Header-Value>
+// -<Header-Name>
 // ...
+//
+// See [HeaderOp] for the meaning of the + and - prefixes.
 func ParseHeadersFile(body io.Reader) (HTTPHeaders, error) {
 	scanner := bufio.NewScanner(body)
 
-	headers := make(HTTPHeaders)
+	headers := HTTPHeaders{}
+	lineNumber := 0
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNumber++
 
 		// skip comments
 		if strings.HasPrefix(line, "#") {
@@ -143,20 +331,257 @@ func ParseHeadersFile(body io.Reader) (HTTPHeaders, error) {
 			return headers, nil
 		}
 
-		colonIdx := strings.Index(line, ":")
-		if colonIdx == -1 {
+		header, err := parseHeaderLine(line)
+		if err != nil {
 			return nil, fmt.Errorf("invalid header: %q", line)
 		}
-
-		key := strings.TrimSpace(line[:colonIdx])
-		value := strings.TrimSpace(line[colonIdx+1:])
-		headers[key] = value
+		header.Line = lineNumber
+		headers = append(headers, header)
 	}
 
 	return headers, nil
 }
 
-func isHTTPMethod(m string) bool {
+// parseHeaderLine parses `Name: Value`, `+Name: Value` and `-Name` header lines
+func parseHeaderLine(line string) (HTTPHeader, error) {
+	if name, ok := strings.CutPrefix(line, "-"); ok {
+		name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), ":"))
+		if name == "" || strings.Contains(name, ":") {
+			return HTTPHeader{}, fmt.Errorf("invalid header")
+		}
+		return HTTPHeader{Name: name, Op: HeaderUnset}, nil
+	}
+
+	op := HeaderSet
+	if rest, ok := strings.CutPrefix(line, "+"); ok {
+		op = HeaderAppend
+		line = rest
+	}
+
+	colonIdx := strings.Index(line, ":")
+	if colonIdx == -1 {
+		return HTTPHeader{}, fmt.Errorf("invalid header")
+	}
+
+	return HTTPHeader{
+		Name:  strings.TrimSpace(line[:colonIdx]),
+		Value: strings.TrimSpace(line[colonIdx+1:]),
+		Op:    op,
+	}, nil
+}
+
+// parseDirective parses @directive line into the request
+func parseDirective(req *HTTPRequest, line string) error {
+	directive, rest, _ := strings.Cut(strings.TrimPrefix(line, "@"), " ")
+
+	switch directive {
+	case "capture":
+		name, source, found := strings.Cut(rest, "=")
+		name, source = strings.TrimSpace(name), strings.TrimSpace(source)
+		if !found || name == "" || source == "" {
+			return fmt.Errorf("invalid capture: %q, expected @capture <name> = <source>", line)
+		}
+		req.Captures = append(req.Captures, Capture{Name: name, Source: source})
+	case "tag":
+		tags := strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+		if len(tags) == 0 {
+			return fmt.Errorf("invalid tag: %q, expected @tag <name>[, <name>...]", line)
+		}
+		req.Tags = append(req.Tags, tags...)
+	case "assert":
+		a, err := parseAssertion(rest)
+		if err != nil {
+			return fmt.Errorf("invalid assertion: %q: %w", line, err)
+		}
+		req.Assertions = append(req.Assertions, a)
+	default:
+		return fmt.Errorf("unknown directive: %q", line)
+	}
+
+	return nil
+}
+
+// Assertion describes an expectation on the response
+//
+// Subject is one of:
+//
+//	status         response status code
+//	header <Name>  value of the response header
+//	$.json.path    value from the JSON response body
+//	body           whole response body
+//	duration       response time in milliseconds
+//
+// Operator is one of ==, !=, <, <=, >, >=, contains, matches or exists.
+type Assertion struct {
+	Subject  string
+	Operator string
+	Expected string
+}
+
+func (a Assertion) String() string {
+	if a.Expected == "" {
+		return fmt.Sprintf("%s %s", a.Subject, a.Operator)
+	}
+	return fmt.Sprintf("%s %s %s", a.Subject, a.Operator, a.Expected)
+}
+
+// parseAssertion parses `<subject> <operator> [expected]`
+func parseAssertion(s string) (Assertion, error) {
+	fields := strings.Fields(s)
+
+	subjectLen := 1
+	if len(fields) > 0 && fields[0] == "header" {
+		subjectLen = 2
+	}
+	if len(fields) < subjectLen+1 {
+		return Assertion{}, fmt.Errorf("expected <subject> <operator> [expected]")
+	}
+
+	a := Assertion{
+		Subject:  strings.Join(fields[:subjectLen], " "),
+		Operator: fields[subjectLen],
+	}
+
+	switch a.Operator {
+	case "exists":
+		if len(fields) > subjectLen+1 {
+			return Assertion{}, fmt.Errorf("exists does not take a value")
+		}
+		return a, nil
+	case "==", "!=", "<", "<=", ">", ">=", "contains", "matches":
+	default:
+		return Assertion{}, fmt.Errorf("unknown operator %q", a.Operator)
+	}
+
+	// keep the original spacing of the expected value
+	rest := strings.TrimSpace(s)
+	for _, f := range fields[:subjectLen+1] {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, f))
+	}
+	if rest == "" {
+		return Assertion{}, fmt.Errorf("missing expected value")
+	}
+	if unquoted, err := strconv.Unquote(rest); err == nil {
+		rest = unquoted
+	}
+	a.Expected = rest
+
+	return a, nil
+}
+
+const partPrefix = "--- "
+
+var partAttributeRegexp = regexp.MustCompile(`(\w+)=("([^"]*)"|\S+)`)
+
+// isMultipart reports whether the Content-Type is multipart/form-data without an explicit boundary
+func isMultipart(headers HTTPHeaders) bool {
+	v, ok := headers.Get("Content-Type")
+	return ok && strings.HasPrefix(strings.ToLower(v), "multipart/form-data") && !strings.Contains(v, "boundary=")
+}
+
+// parseParts parses multipart/form-data parts, see [Part]
+func parseParts(lines []string) ([]Part, error) {
+	parts := []Part{}
+	valueLines := []string{}
+
+	flush := func() {
+		if len(parts) == 0 {
+			return
+		}
+		// trailing empty lines separate the parts
+		for len(valueLines) > 0 && strings.TrimSpace(valueLines[len(valueLines)-1]) == "" {
+			valueLines = valueLines[:len(valueLines)-1]
+		}
+		value := strings.Join(valueLines, "\n")
+		last := &parts[len(parts)-1]
+		if path, _, ok := cutBodyFile(value); ok {
+			last.File = path
+		} else {
+			last.Value = value
+		}
+		valueLines = []string{}
+	}
+
+	for _, line := range lines {
+		header, ok := strings.CutPrefix(line, partPrefix)
+		if !ok {
+			if len(parts) == 0 && strings.TrimSpace(line) == "" {
+				continue
+			}
+			valueLines = append(valueLines, line)
+			continue
+		}
+
+		flush()
+
+		fields := strings.Fields(header)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid part: %q, expected --- <name>", line)
+		}
+		part := Part{Name: fields[0]}
+		for _, m := range partAttributeRegexp.FindAllStringSubmatch(strings.TrimPrefix(strings.TrimSpace(header), fields[0]), -1) {
+			value := m[2]
+			if m[3] != "" || strings.HasPrefix(value, "\"") {
+				value = m[3]
+			}
+			switch m[1] {
+			case "filename":
+				part.Filename = value
+			case "type":
+				part.ContentType = value
+			default:
+				return nil, fmt.Errorf("invalid part: %q, unknown attribute %s", line, m[1])
+			}
+		}
+		parts = append(parts, part)
+	}
+	flush()
+
+	return parts, nil
+}
+
+// cutBodyFile reports whether the body is a `< path` or `<@ path` file reference
+func cutBodyFile(body string) (string, bool, bool) {
+	line := strings.TrimSpace(body)
+	if strings.Contains(line, "\n") {
+		return "", false, false
+	}
+
+	if path, ok := strings.CutPrefix(line, "<@"); ok && strings.TrimSpace(path) != "" {
+		return strings.TrimSpace(path), true, true
+	}
+	if path, ok := strings.CutPrefix(line, "<"); ok && strings.HasPrefix(path, " ") && strings.TrimSpace(path) != "" {
+		return strings.TrimSpace(path), false, true
+	}
+	return "", false, false
+}
+
+// cutSeparator reports whether the line is a ### request separator and returns its name
+func cutSeparator(line string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, "###")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// isBlank reports whether lines contain only whitespace and comments
+func isBlank(lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+func slugify(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(s, "-", " "))), "-")
+}
+
+// IsHTTPMethod reports whether m is a method supported in the request line
+func IsHTTPMethod(m string) bool {
 	switch strings.ToUpper(m) {
 	case "GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "HEAD":
 		return true
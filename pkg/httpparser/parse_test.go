@@ -40,6 +40,11 @@ func validHTTPFileHeadersOnly() string {
 	return validRequestLine() + validHeaders()
 }
 
+func headerValue(headers HTTPHeaders, name string) string {
+	v, _ := headers.Get(name)
+	return v
+}
+
 // Test Parse
 
 func TestParseHTTPFile(t *testing.T) {
@@ -49,8 +54,8 @@ func TestParseHTTPFile(t *testing.T) {
 	assert.Eq(t, req.Method, validMethod())
 	assert.Eq(t, validURL(), req.URL)
 	assert.Eq(t, 2, len(req.Headers))
-	assert.Eq(t, "application/json", req.Headers["Content-Type"])
-	assert.Eq(t, "test", req.Headers["Authorization"])
+	assert.Eq(t, "application/json", headerValue(req.Headers, "Content-Type"))
+	assert.Eq(t, "test", headerValue(req.Headers, "Authorization"))
 	assert.Eq(t, validContent(), req.Body)
 }
 
@@ -71,8 +76,8 @@ func TestParseHTTPFileHeadersOnly(t *testing.T) {
 	assert.Eq(t, validMethod(), req.Method)
 	assert.Eq(t, validURL(), req.URL)
 	assert.Eq(t, 2, len(req.Headers))
-	assert.Eq(t, "application/json", req.Headers["Content-Type"])
-	assert.Eq(t, "test", req.Headers["Authorization"])
+	assert.Eq(t, "application/json", headerValue(req.Headers, "Content-Type"))
+	assert.Eq(t, "test", headerValue(req.Headers, "Authorization"))
 	assert.Eq(t, "", req.Body)
 }
 
@@ -99,6 +104,177 @@ func TestParseHeadersFile(t *testing.T) {
 	headers, err := ParseHeadersFile(bytes.NewBufferString(b))
 	assert.Eq(t, nil, err)
 	assert.Eq(t, 2, len(headers))
-	assert.Eq(t, "application/json", headers["Content-Type"])
-	assert.Eq(t, "test", headers["Authorization"])
+	assert.Eq(t, "application/json", headerValue(headers, "Content-Type"))
+	assert.Eq(t, "test", headerValue(headers, "Authorization"))
+}
+
+// Test ParseAll
+
+func TestParseAllMultipleRequests(t *testing.T) {
+	b := validHTTPFile() + "\n\n### create user\n" + validHTTPFileContentOnly() + "\n###\nGET " + validURL() + "\n"
+	reqs, err := ParseAll(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 3, len(reqs))
+	assert.Eq(t, "", reqs[0].Name)
+	assert.Eq(t, validContent(), reqs[0].Body)
+	assert.Eq(t, "create user", reqs[1].Name)
+	assert.Eq(t, validMethod(), reqs[1].Method)
+	assert.Eq(t, validContent(), reqs[1].Body)
+	assert.Eq(t, "GET", reqs[2].Method)
+	assert.Eq(t, "", reqs[2].Body)
+}
+
+func TestParseTrailingBlankLines(t *testing.T) {
+	req, err := Parse(bytes.NewBufferString("POST " + validURL() + "\n\nbody\n\n\n"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "body\n\n", req.Body)
+
+	reqs, err := ParseAll(bytes.NewBufferString("POST " + validURL() + "\n\nfirst\n\n\n### second\nPOST " + validURL() + "\n\nsecond\n\n"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "first", reqs[0].Body)
+	assert.Eq(t, "second\n", reqs[1].Body)
+}
+
+func TestParseAllLeadingSeparator(t *testing.T) {
+	b := "### first\n" + validHTTPFileHeadersOnly()
+	reqs, err := ParseAll(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 1, len(reqs))
+	assert.Eq(t, "first", reqs[0].Name)
+	assert.Eq(t, 2, len(reqs[0].Headers))
+}
+
+func TestSelect(t *testing.T) {
+	reqs := []*HTTPRequest{{Name: "list users"}, {Name: "Create User"}}
+
+	req, err := Select(reqs, "create-user")
+	assert.Eq(t, nil, err)
+	assert.Eq(t, reqs[1], req)
+
+	req, err = Select(reqs, "1")
+	assert.Eq(t, nil, err)
+	assert.Eq(t, reqs[0], req)
+
+	_, err = Select(reqs, "3")
+	assert.Neq(t, nil, err)
+
+	_, err = Select(reqs, "delete-user")
+	assert.Neq(t, nil, err)
+}
+
+func TestParseCaptures(t *testing.T) {
+	b := "@capture code = status\n" + validRequestLine() + "@capture token = $.data.access_token\n" + validHeaders()
+	req, err := Parse(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 2, len(req.Headers))
+	assert.Eq(t, 2, len(req.Captures))
+	assert.Eq(t, Capture{Name: "code", Source: "status"}, req.Captures[0])
+	assert.Eq(t, Capture{Name: "token", Source: "$.data.access_token"}, req.Captures[1])
+
+	_, err = Parse(bytes.NewBufferString(validRequestLine() + "@capture token\n"))
+	assert.Neq(t, nil, err)
+}
+
+func TestParseAssertions(t *testing.T) {
+	b := validRequestLine() + "@assert status == 200\n@assert header Content-Type contains \"json; charset\"\n@assert $.id exists\n" + validHeaders()
+	req, err := Parse(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 3, len(req.Assertions))
+	assert.Eq(t, Assertion{Subject: "status", Operator: "==", Expected: "200"}, req.Assertions[0])
+	assert.Eq(t, Assertion{Subject: "header Content-Type", Operator: "contains", Expected: "json; charset"}, req.Assertions[1])
+	assert.Eq(t, Assertion{Subject: "$.id", Operator: "exists"}, req.Assertions[2])
+
+	_, err = Parse(bytes.NewBufferString(validRequestLine() + "@assert status is 200\n"))
+	assert.Neq(t, nil, err)
+}
+
+func TestParseTags(t *testing.T) {
+	b := "@tag smoke\n" + validRequestLine() + "@tag users, slow\n"
+	req, err := Parse(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 3, len(req.Tags))
+	assert.Eq(t, "smoke", req.Tags[0])
+	assert.Eq(t, "users", req.Tags[1])
+	assert.Eq(t, "slow", req.Tags[2])
+}
+
+func TestParseBodyFile(t *testing.T) {
+	req, err := Parse(bytes.NewBufferString(validRequestLine() + "\n< ./payloads/user.json\n"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "", req.Body)
+	assert.Eq(t, "./payloads/user.json", req.BodyFile)
+	assert.Eq(t, false, req.ExpandBodyFile)
+
+	req, err = Parse(bytes.NewBufferString(validRequestLine() + "\n<@ ./tpl.json"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "./tpl.json", req.BodyFile)
+	assert.Eq(t, true, req.ExpandBodyFile)
+
+	// not a file reference when mixed with other content
+	req, err = Parse(bytes.NewBufferString(validRequestLine() + "\n< ./a.json\nmore"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "", req.BodyFile)
+	assert.Eq(t, "< ./a.json\nmore", req.Body)
+}
+
+func TestParseMultipart(t *testing.T) {
+	b := validRequestLine() + "Content-Type: multipart/form-data\n\n--- description\nMy avatar\n\n--- avatar filename=\"me 2.png\" type=image/png\n< ./me.png\n"
+	req, err := Parse(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "", req.Body)
+	assert.Eq(t, 2, len(req.Parts))
+	assert.Eq(t, Part{Name: "description", Value: "My avatar"}, req.Parts[0])
+	assert.Eq(t, Part{Name: "avatar", Filename: "me 2.png", ContentType: "image/png", File: "./me.png"}, req.Parts[1])
+
+	// explicit boundary keeps the raw body
+	b = validRequestLine() + "Content-Type: multipart/form-data; boundary=x\n\n--- description\n"
+	req, err = Parse(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 0, len(req.Parts))
+	assert.Eq(t, "--- description", req.Body)
+}
+
+func TestParseRepeatedHeaders(t *testing.T) {
+	b := validRequestLine() + "Accept: text/html\nCookie: a=1\nAccept: application/json\n"
+	req, err := Parse(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 3, len(req.Headers))
+	assert.Eq(t, 2, len(req.Headers.Values("accept")))
+	assert.Eq(t, validRequestLine()+"Accept: text/html\nCookie: a=1\nAccept: application/json\n", req.String())
+}
+
+func TestParseHeadersFileOps(t *testing.T) {
+	headers, err := ParseHeadersFile(bytes.NewBufferString("-Authorization\n+Accept: text/csv\nX-A: 1\n"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 3, len(headers))
+	assert.Eq(t, HTTPHeader{Name: "Authorization", Op: HeaderUnset, Line: 1}, headers[0])
+	assert.Eq(t, HTTPHeader{Name: "Accept", Value: "text/csv", Op: HeaderAppend, Line: 2}, headers[1])
+	assert.Eq(t, HTTPHeader{Name: "X-A", Value: "1", Line: 3}, headers[2])
+	assert.Eq(t, "-Authorization", headers[0].String())
+	assert.Eq(t, "+Accept: text/csv", headers[1].String())
+}
+
+func TestParseAllHeaderLines(t *testing.T) {
+	b := "# comment\n" + validHTTPFile() + "\n###\n" + validHTTPFileHeadersOnly()
+	reqs, err := ParseAll(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 2, len(reqs))
+	assert.Eq(t, 3, reqs[0].Headers[0].Line)
+	assert.Eq(t, 4, reqs[0].Headers[1].Line)
+	assert.Eq(t, 9, reqs[1].Headers[0].Line)
+}
+
+func TestSource(t *testing.T) {
+	sources := []string{
+		"### create\n@tag smoke, users\n@capture id = $.id\n@assert status == 201\nPOST http://localhost/users\nContent-Type: application/json\n-Authorization\n\n{\"name\": \"John\"}\n",
+		"PUT http://localhost/users\n\n<@ ./user.json\n",
+		"POST http://localhost/upload\nContent-Type: multipart/form-data\n\n--- title\nMy file\n--- file filename=\"a b.png\" type=image/png\n< ./a.png\n",
+		"GET http://localhost/users\n",
+	}
+
+	for _, source := range sources {
+		req, err := Parse(bytes.NewBufferString(source))
+		assert.Eq(t, nil, err)
+		assert.Eq(t, source, req.Source())
+	}
 }
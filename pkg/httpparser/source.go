@@ -0,0 +1,64 @@
+package httpparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source renders the request in the .http file syntax accepted by [ParseAll]
+//
+// Unlike [HTTPRequest.String] it keeps the name, the directives and the body file and part references.
+func (req *HTTPRequest) Source() string {
+	var b strings.Builder
+
+	if req.Name != "" {
+		fmt.Fprintf(&b, "### %s\n", req.Name)
+	}
+	if len(req.Tags) > 0 {
+		fmt.Fprintf(&b, "@tag %s\n", strings.Join(req.Tags, ", "))
+	}
+	for _, c := range req.Captures {
+		fmt.Fprintf(&b, "@capture %s = %s\n", c.Name, c.Source)
+	}
+	for _, a := range req.Assertions {
+		fmt.Fprintf(&b, "@assert %s\n", a)
+	}
+
+	fmt.Fprintf(&b, "%s %s\n", req.Method, req.URL)
+	for _, h := range req.Headers {
+		b.WriteString(h.String() + "\n")
+	}
+
+	switch {
+	case len(req.Parts) > 0:
+		b.WriteString("\n")
+		for _, part := range req.Parts {
+			b.WriteString(partPrefix + part.Name)
+			if part.Filename != "" {
+				fmt.Fprintf(&b, " filename=%q", part.Filename)
+			}
+			if part.ContentType != "" {
+				fmt.Fprintf(&b, " type=%s", part.ContentType)
+			}
+			b.WriteString("\n")
+			if part.File != "" {
+				fmt.Fprintf(&b, "< %s\n", part.File)
+			} else {
+				b.WriteString(part.Value + "\n")
+			}
+		}
+	case req.BodyFile != "":
+		if req.ExpandBodyFile {
+			fmt.Fprintf(&b, "\n<@ %s\n", req.BodyFile)
+		} else {
+			fmt.Fprintf(&b, "\n< %s\n", req.BodyFile)
+		}
+	case req.Body != "":
+		b.WriteString("\n" + req.Body)
+		if !strings.HasSuffix(req.Body, "\n") {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
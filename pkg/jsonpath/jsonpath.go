@@ -0,0 +1,129 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Get evaluates a JSONPath expression against a decoded JSON document
+//
+// Only a subset of JSONPath is supported:
+//
+//	$                root of the document
+//	.key             object member
+//	['key'], ["key"] object member with special characters
+//	[n]              array element, negative n counts from the end
+//
+// Example:
+//
+//	doc := map[string]any{"data": []any{map[string]any{"id": "1"}}}
+//	v, err := Get(doc, "$.data[0].id")
+//	// v == "1"
+func Get(doc any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, fmt.Errorf("path must start with $: %q", path)
+	}
+
+	current := doc
+	for rest != "" {
+		var segment string
+		var err error
+
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			segment, rest = rest[:end], rest[end:]
+			if segment == "" {
+				return nil, fmt.Errorf("empty member name in %q", path)
+			}
+			current, err = member(current, segment)
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in %q", path)
+			}
+			segment, rest = rest[1:end], rest[end+1:]
+			if unquoted, ok := unquote(segment); ok {
+				current, err = member(current, unquoted)
+			} else {
+				current, err = index(current, segment)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", rest[0], path)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return current, nil
+}
+
+// GetBytes decodes data as JSON and evaluates path against it, see [Get]
+func GetBytes(data []byte, path string) (any, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+	return Get(doc, path)
+}
+
+// String formats the value returned by [Get]
+//
+// Strings are returned as is, everything else is encoded as JSON.
+func String(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func member(v any, key string) (any, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot get %q of non-object", key)
+	}
+	val, ok := obj[key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", key)
+	}
+	return val, nil
+}
+
+func index(v any, segment string) (any, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot index non-array with [%s]", segment)
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(segment))
+	if err != nil {
+		return nil, fmt.Errorf("invalid index [%s]", segment)
+	}
+	if i < 0 {
+		i += len(arr)
+	}
+	if i < 0 || i >= len(arr) {
+		return nil, fmt.Errorf("index [%s] out of range", segment)
+	}
+	return arr[i], nil
+}
+
+func unquote(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
@@ -0,0 +1,41 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func validDocument() []byte {
+	return []byte(`{"data": {"access_token": "abc", "items": [{"id": 1}, {"id": 2}], "weird.key": true}}`)
+}
+
+func TestGetBytes(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{"$.data.access_token", "abc", false},
+		{"$.data.items[0].id", "1", false},
+		{"$.data.items[-1].id", "2", false},
+		{"$['data']['weird.key']", "true", false},
+		{"$.data.items", `[{"id":1},{"id":2}]`, false},
+		{"$.data.missing", "", true},
+		{"$.data.items[5]", "", true},
+		{"data", "", true},
+	}
+
+	for _, tt := range tests {
+		v, err := GetBytes(validDocument(), tt.path)
+		assert.Eq(t, tt.wantErr, err != nil)
+		if err == nil {
+			assert.Eq(t, tt.expected, String(v))
+		}
+	}
+}
+
+func TestGetBytesInvalidJSON(t *testing.T) {
+	_, err := GetBytes([]byte("not json"), "$.a")
+	assert.Neq(t, nil, err)
+}
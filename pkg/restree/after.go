@@ -0,0 +1,134 @@
+package restree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// RunAfterScripts runs the `_after.sh` scripts of the tree after the request was executed
+//
+// The scripts run from the directory of the target up to the root, with the environment of [scriptContext]
+// describing the sent request and additionally:
+//
+//	RESTREE_STATUS                   status code of the response
+//	RESTREE_RESPONSE_HEADER_<NAME>   headers of the response, named like RESTREE_HEADER_<NAME>
+//	RESTREE_RESPONSE_HEADERS_FILE    path of a JSON file with the headers of the response
+//	RESTREE_BODY_FILE                path of a file with the body of the response
+//
+// The body of the response is also passed on stdin. The `key=value` lines printed by a script are added
+// to the captured variables of the result and are visible to the following scripts.
+// A script exiting with a non-zero code fails the run.
+func RunAfterScripts(from string, to string, req *httpparser.HTTPRequest, result *Result, variables Variables, opts RecursiveReadOpts) error {
+	levels, err := treeLevels(from, to)
+	if err != nil {
+		return err
+	}
+
+	ctx := scriptContext{
+		Root:    from,
+		Target:  to,
+		Request: opts.Request,
+		Method:  req.Method,
+		URL:     req.URL,
+		Headers: req.Headers,
+	}
+
+	variables = maps.Clone(variables)
+	maps.Copy(variables, result.Captured)
+	if result.Captured == nil {
+		result.Captured = Variables{}
+	}
+
+	for _, level := range slices.Backward(levels) {
+		scriptPath := filepath.Join(level, AfterScriptFileName)
+		if _, err := os.Stat(filepath.Join(from, scriptPath)); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("unable to stat after script: %w", err)
+		}
+
+		ctx.Dir = filepath.Join(from, level)
+		exported, err := runAfterScript(ctx, scriptPath, result, variables, opts)
+		if err != nil {
+			return fmt.Errorf("failed to execute after script %s: %w", scriptPath, err)
+		}
+
+		maps.Copy(variables, exported)
+		maps.Copy(result.Captured, exported)
+	}
+
+	return nil
+}
+
+func runAfterScript(ctx scriptContext, scriptPath string, result *Result, variables Variables, opts RecursiveReadOpts) (Variables, error) {
+	env, cleanup, err := ctx.environ(variables)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	env, cleanupResponse, err := responseEnviron(env, result)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupResponse()
+
+	stdout, err := runScript(filepath.Join(ctx.Root, scriptPath), env, bytes.NewReader(result.Body), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	exported, err := parseScriptEnvOutput(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse envs: %s", err)
+	}
+	return exported, nil
+}
+
+// responseEnviron adds the response of the result to the environment of a script
+func responseEnviron(env []string, result *Result) ([]string, func(), error) {
+	resp := result.Response
+	env = append(env, "RESTREE_STATUS="+strconv.Itoa(resp.StatusCode))
+
+	headers := []scriptHeader{}
+	for _, name := range slices.Sorted(maps.Keys(resp.Header)) {
+		for _, value := range resp.Header[name] {
+			headers = append(headers, scriptHeader{Name: name, Value: value})
+		}
+		env = append(env, "RESTREE_RESPONSE_"+strings.TrimPrefix(headerEnvName(name), "RESTREE_")+"="+strings.Join(resp.Header[name], ", "))
+	}
+
+	headersFile, err := writeTempFile("restree-response-headers-*.json", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(headers)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot write response headers file: %w", err)
+	}
+
+	bodyFile, err := writeTempFile("restree-body-*", func(w io.Writer) error {
+		_, err := w.Write(result.Body)
+		return err
+	})
+	if err != nil {
+		os.Remove(headersFile) //nolint:errcheck
+		return nil, nil, fmt.Errorf("cannot write body file: %w", err)
+	}
+
+	env = append(env, "RESTREE_RESPONSE_HEADERS_FILE="+headersFile, "RESTREE_BODY_FILE="+bodyFile)
+
+	return env, func() {
+		os.Remove(headersFile) //nolint:errcheck
+		os.Remove(bodyFile)    //nolint:errcheck
+	}, nil
+}
@@ -0,0 +1,67 @@
+package restree
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func validResult(body string) *Result {
+	return &Result{
+		Response: &http.Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"X-Request-Id": {"42"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		},
+		Body:     []byte(body),
+		Captured: Variables{"id": "7"},
+	}
+}
+
+func TestRunAfterScripts(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		AfterScriptFileName: `echo "root_saw=$from_users"`,
+		"users/" + AfterScriptFileName: `echo "status=$RESTREE_STATUS"
+echo "request_id=$RESTREE_RESPONSE_HEADER_X_REQUEST_ID"
+echo "body=$(cat)"
+echo "body_file=$(cat "$RESTREE_BODY_FILE")"
+echo "captured=$id"
+echo "from_users=yes"
+`,
+		"users/create.http": "POST http://localhost/users\n",
+	})
+	target := filepath.Join(dir, "users", "create.http")
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, target, Variables{}, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+
+	result := validResult(`{"token":"abc"}`)
+	err = RunAfterScripts(dir, target, req, result, Variables{}, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+
+	assert.Eq(t, "201", result.Captured["status"])
+	assert.Eq(t, "42", result.Captured["request_id"])
+	assert.Eq(t, `{"token":"abc"}`, result.Captured["body"])
+	assert.Eq(t, `{"token":"abc"}`, result.Captured["body_file"])
+	assert.Eq(t, "7", result.Captured["captured"])
+	assert.Eq(t, "yes", result.Captured["root_saw"])
+}
+
+func TestRunAfterScriptsFailure(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		AfterScriptFileName: "exit 3\n",
+		"get.http":          "GET http://localhost/\n",
+	})
+	target := filepath.Join(dir, "get.http")
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, target, Variables{}, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+
+	err = RunAfterScripts(dir, target, req, validResult(""), Variables{}, RecursiveReadOpts{})
+	assert.Neq(t, nil, err)
+}
@@ -0,0 +1,134 @@
+package restree
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/jsonpath"
+)
+
+// AssertionResult is the outcome of a single [httpparser.Assertion]
+type AssertionResult struct {
+	Assertion httpparser.Assertion
+	// Actual is the value the assertion was checked against
+	Actual string
+	// Err is nil when the assertion passed
+	Err error
+}
+
+func (r AssertionResult) Passed() bool {
+	return r.Err == nil
+}
+
+// CheckAssertions evaluates the assertions against the response
+//
+// body is the already read response body and duration is the time it took to receive the response.
+func CheckAssertions(assertions []httpparser.Assertion, resp *http.Response, body []byte, duration time.Duration) []AssertionResult {
+	results := make([]AssertionResult, 0, len(assertions))
+
+	for _, a := range assertions {
+		actual, found, err := assertionSubject(a.Subject, resp, body, duration)
+		if err == nil {
+			err = compare(a, actual, found)
+		}
+		results = append(results, AssertionResult{
+			Assertion: a,
+			Actual:    actual,
+			Err:       err,
+		})
+	}
+
+	return results
+}
+
+// assertionSubject resolves the subject of the assertion and reports whether it was found
+func assertionSubject(subject string, resp *http.Response, body []byte, duration time.Duration) (string, bool, error) {
+	switch {
+	case strings.HasPrefix(subject, "$"):
+		v, err := jsonpath.GetBytes(body, subject)
+		if err != nil {
+			// missing values are reported by the operator
+			return "", false, nil
+		}
+		return jsonpath.String(v), true, nil
+	case subject == "status":
+		return strconv.Itoa(resp.StatusCode), true, nil
+	case subject == "body":
+		return string(body), true, nil
+	case subject == "duration":
+		return strconv.FormatInt(duration.Milliseconds(), 10), true, nil
+	}
+
+	if name, ok := strings.CutPrefix(subject, "header "); ok {
+		values, found := resp.Header[http.CanonicalHeaderKey(name)]
+		return strings.Join(values, ", "), found, nil
+	}
+
+	return "", false, fmt.Errorf("unknown subject: %q", subject)
+}
+
+func compare(a httpparser.Assertion, actual string, found bool) error {
+	if a.Operator == "exists" {
+		if !found {
+			return fmt.Errorf("%s does not exist", a.Subject)
+		}
+		return nil
+	}
+	if !found {
+		return fmt.Errorf("%s not found", a.Subject)
+	}
+
+	var ok bool
+	switch a.Operator {
+	case "==":
+		ok = equal(actual, a.Expected)
+	case "!=":
+		ok = !equal(actual, a.Expected)
+	case "contains":
+		ok = strings.Contains(actual, a.Expected)
+	case "matches":
+		re, err := regexp.Compile(a.Expected)
+		if err != nil {
+			return fmt.Errorf("invalid regexp: %w", err)
+		}
+		ok = re.MatchString(actual)
+	case "<", "<=", ">", ">=":
+		x, errX := strconv.ParseFloat(actual, 64)
+		y, errY := strconv.ParseFloat(a.Expected, 64)
+		if errX != nil || errY != nil {
+			return fmt.Errorf("cannot compare %q %s %q as numbers", actual, a.Operator, a.Expected)
+		}
+		switch a.Operator {
+		case "<":
+			ok = x < y
+		case "<=":
+			ok = x <= y
+		case ">":
+			ok = x > y
+		case ">=":
+			ok = x >= y
+		}
+	default:
+		return fmt.Errorf("unknown operator: %q", a.Operator)
+	}
+
+	if !ok {
+		return fmt.Errorf("expected %s %s %s, got %q", a.Subject, a.Operator, a.Expected, actual)
+	}
+	return nil
+}
+
+// equal compares values as numbers when both are numeric and as strings otherwise
+func equal(actual string, expected string) bool {
+	x, errX := strconv.ParseFloat(actual, 64)
+	y, errY := strconv.ParseFloat(expected, 64)
+	if errX == nil && errY == nil {
+		return x == y
+	}
+	return actual == expected
+}
@@ -0,0 +1,40 @@
+package restree
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+func TestCheckAssertions(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}
+	body := []byte(`{"data": {"id": 5, "name": "John"}}`)
+
+	tests := []struct {
+		assertion httpparser.Assertion
+		passed    bool
+	}{
+		{httpparser.Assertion{Subject: "status", Operator: "==", Expected: "200"}, true},
+		{httpparser.Assertion{Subject: "status", Operator: "!=", Expected: "200"}, false},
+		{httpparser.Assertion{Subject: "header Content-Type", Operator: "contains", Expected: "json"}, true},
+		{httpparser.Assertion{Subject: "header X-Missing", Operator: "exists"}, false},
+		{httpparser.Assertion{Subject: "$.data.id", Operator: "==", Expected: "5.0"}, true},
+		{httpparser.Assertion{Subject: "$.data.name", Operator: "matches", Expected: "^J"}, true},
+		{httpparser.Assertion{Subject: "$.data.missing", Operator: "==", Expected: "x"}, false},
+		{httpparser.Assertion{Subject: "body", Operator: "contains", Expected: "John"}, true},
+		{httpparser.Assertion{Subject: "duration", Operator: "<", Expected: "100"}, true},
+		{httpparser.Assertion{Subject: "duration", Operator: ">", Expected: "abc"}, false},
+	}
+
+	for _, tt := range tests {
+		results := CheckAssertions([]httpparser.Assertion{tt.assertion}, resp, body, 50*time.Millisecond)
+		assert.Eq(t, 1, len(results))
+		assert.Assert(t, results[0].Passed() == tt.passed, tt.assertion.String())
+	}
+}
@@ -0,0 +1,121 @@
+package restree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ScriptCache stores the output of scripts declaring a TTL with `# restree: cache=<duration>`
+//
+// A nil *ScriptCache caches nothing.
+type ScriptCache struct {
+	Dir string
+}
+
+// DefaultScriptCacheDir returns the restree directory in the user cache directory, `$XDG_CACHE_HOME/restree/scripts` on Linux
+func DefaultScriptCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "restree", "scripts"), nil
+}
+
+// Get returns the cached output stored under the key when it is younger than the TTL
+func (c *ScriptCache) Get(key string, ttl time.Duration) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	path := filepath.Join(c.Dir, key)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) >= ttl {
+		return "", false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put stores the output under the key
+func (c *ScriptCache) Put(key string, output string) error {
+	if c == nil {
+		return nil
+	}
+
+	// the outputs usually contain credentials
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return fmt.Errorf("unable to create cache dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.Dir, key), []byte(output), 0o600); err != nil {
+		return fmt.Errorf("unable to write cache: %w", err)
+	}
+	return nil
+}
+
+// Clear removes all the cached outputs
+func (c *ScriptCache) Clear() error {
+	if c == nil {
+		return nil
+	}
+	return os.RemoveAll(c.Dir)
+}
+
+// scriptCacheTTL returns the TTL declared by the script, zero when the script is not cached
+func scriptCacheTTL(script []byte) (time.Duration, error) {
+	for _, line := range strings.Split(string(script), "\n") {
+		directives, ok := strings.CutPrefix(strings.TrimSpace(line), directivePrefix)
+		if !ok {
+			continue
+		}
+		for _, d := range strings.Fields(directives) {
+			value, ok := strings.CutPrefix(d, "cache=")
+			if !ok {
+				continue
+			}
+			ttl, err := time.ParseDuration(value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid cache directive: %w", err)
+			}
+			return ttl, nil
+		}
+	}
+	return 0, nil
+}
+
+// scriptCacheKey hashes the script path, its interpreter, its content and the environment variables the script references by name
+func scriptCacheKey(scriptPath string, interpreter string, script []byte, env []string) string {
+	values := map[string]string{}
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		values[name] = value
+	}
+
+	referenced := identifierRegexp.FindAllString(string(script), -1)
+	slices.Sort(referenced)
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00", scriptPath, interpreter, script)
+	for _, name := range slices.Compact(referenced) {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		// temporary files like RESTREE_HEADERS_FILE change on every run, their content does not
+		if strings.HasPrefix(name, "RESTREE_") && strings.HasSuffix(name, "_FILE") {
+			content, _ := os.ReadFile(value)
+			value = string(content)
+		}
+		_, _ = fmt.Fprintf(h, "%s=%s\x00", name, value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
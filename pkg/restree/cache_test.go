@@ -0,0 +1,76 @@
+package restree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func TestScriptCacheTTL(t *testing.T) {
+	ttl, err := scriptCacheTTL([]byte("#!/bin/sh\n# restree: cache=30m\necho token=abc\n"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 30*time.Minute, ttl)
+
+	ttl, err = scriptCacheTTL([]byte("echo token=abc\n"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, time.Duration(0), ttl)
+
+	_, err = scriptCacheTTL([]byte("# restree: cache=soon\n"))
+	assert.Neq(t, nil, err)
+}
+
+func TestScriptCacheKey(t *testing.T) {
+	script := []byte("curl -d \"user=$user\" $auth_url\n")
+	key := scriptCacheKey("/root/_before.sh", "/bin/sh", script, []string{"user=john", "auth_url=http://auth", "TERM=xterm"})
+
+	assert.Eq(t, key, scriptCacheKey("/root/_before.sh", "/bin/sh", script, []string{"user=john", "auth_url=http://auth", "TERM=screen"}))
+	assert.Neq(t, key, scriptCacheKey("/root/_before.sh", "/bin/sh", script, []string{"user=jane", "auth_url=http://auth", "TERM=xterm"}))
+	assert.Neq(t, key, scriptCacheKey("/other/_before.sh", "/bin/sh", script, []string{"user=john", "auth_url=http://auth", "TERM=xterm"}))
+	assert.Neq(t, key, scriptCacheKey("/root/_before.sh", "/bin/bash", script, []string{"user=john", "auth_url=http://auth", "TERM=xterm"}))
+}
+
+func TestRecursiveReadFSScriptCache(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		BeforeScriptFileName: "# restree: cache=1h\necho run >> runs.txt\necho \"token=$(wc -l < runs.txt | tr -d ' ')\"\n",
+		"get.http":           "GET http://localhost/\nAuthorization: Bearer {{token}}\n",
+	})
+	opts := RecursiveReadOpts{ScriptCache: &ScriptCache{Dir: t.TempDir()}}
+
+	for range 3 {
+		req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "get.http"), Variables{}, opts)
+		assert.Eq(t, nil, err)
+		assert.Eq(t, "Bearer 1", req.Headers.Values("Authorization")[0])
+	}
+
+	assert.Eq(t, nil, opts.ScriptCache.Clear())
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "get.http"), Variables{}, opts)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "Bearer 2", req.Headers.Values("Authorization")[0])
+}
+
+func TestRecursiveReadFSScriptCacheShell(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		BeforeScriptFileName: "# restree: cache=1h\necho run >> runs.txt\necho \"token=$(wc -l < runs.txt | tr -d ' ')\"\n",
+		"get.http":           "GET http://localhost/\nAuthorization: Bearer {{token}}\n",
+	})
+	opts := RecursiveReadOpts{ScriptCache: &ScriptCache{Dir: t.TempDir()}}
+
+	for _, tt := range []struct {
+		shell string
+		token string
+	}{
+		{"", "Bearer 1"},
+		{"/bin/sh", "Bearer 1"},
+		{"/bin/bash", "Bearer 2"},
+		{"/bin/bash", "Bearer 2"},
+	} {
+		opts.ScriptShell = tt.shell
+		req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "get.http"), Variables{}, opts)
+		assert.Eq(t, nil, err)
+		assert.Eq(t, tt.token, req.Headers.Values("Authorization")[0])
+	}
+}
@@ -0,0 +1,111 @@
+package restree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/jsonpath"
+)
+
+// CapturedVariablesFileName is the file in the root directory where captured variables are persisted
+const CapturedVariablesFileName = "_captured.env"
+
+// Capture evaluates the captures against the response
+//
+// body is the already read response body.
+func Capture(captures []httpparser.Capture, resp *http.Response, body []byte) (Variables, error) {
+	captured := make(Variables)
+
+	for _, c := range captures {
+		value, err := evaluateCapture(c.Source, resp, body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to capture %s: %w", c.Name, err)
+		}
+		captured[c.Name] = value
+	}
+
+	return captured, nil
+}
+
+func evaluateCapture(source string, resp *http.Response, body []byte) (string, error) {
+	switch {
+	case strings.HasPrefix(source, "$"):
+		v, err := jsonpath.GetBytes(body, source)
+		if err != nil {
+			return "", err
+		}
+		return jsonpath.String(v), nil
+	case source == "status":
+		return strconv.Itoa(resp.StatusCode), nil
+	case source == "body":
+		return string(body), nil
+	}
+
+	if name, ok := strings.CutPrefix(source, "header "); ok {
+		name = strings.TrimSpace(name)
+		if _, ok := resp.Header[http.CanonicalHeaderKey(name)]; !ok {
+			return "", fmt.Errorf("header %q not found", name)
+		}
+		return resp.Header.Get(name), nil
+	}
+
+	return "", fmt.Errorf("unknown capture source: %q", source)
+}
+
+// LoadCapturedVariables reads variables persisted by [SaveCapturedVariables]
+//
+// A missing file results in no variables.
+func LoadCapturedVariables(path string) (Variables, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Variables{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read captured variables: %w", err)
+	}
+
+	variables := make(Variables)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		variables[key] = value
+	}
+
+	return variables, scanner.Err()
+}
+
+// SaveCapturedVariables merges captured into the variables persisted at path
+func SaveCapturedVariables(path string, captured Variables) error {
+	variables, err := LoadCapturedVariables(path)
+	if err != nil {
+		return err
+	}
+	maps.Copy(variables, captured)
+
+	var buf bytes.Buffer
+	for _, key := range slices.Sorted(maps.Keys(variables)) {
+		fmt.Fprintf(&buf, "%s=%s\n", key, strconv.Quote(variables[key]))
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("unable to save captured variables: %w", err)
+	}
+
+	return nil
+}
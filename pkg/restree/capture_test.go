@@ -0,0 +1,45 @@
+package restree
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+func TestCapture(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 201,
+		Header:     http.Header{"Etag": []string{"v1"}},
+	}
+	body := []byte(`{"data": {"access_token": "abc"}}`)
+
+	captured, err := Capture([]httpparser.Capture{
+		{Name: "token", Source: "$.data.access_token"},
+		{Name: "etag", Source: "header ETag"},
+		{Name: "code", Source: "status"},
+	}, resp, body)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "abc", captured["token"])
+	assert.Eq(t, "v1", captured["etag"])
+	assert.Eq(t, "201", captured["code"])
+
+	_, err = Capture([]httpparser.Capture{{Name: "x", Source: "header Missing"}}, resp, body)
+	assert.Neq(t, nil, err)
+}
+
+func TestSaveCapturedVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CapturedVariablesFileName)
+
+	err := SaveCapturedVariables(path, Variables{"token": "abc", "body": "line1\nline2"})
+	assert.Eq(t, nil, err)
+	err = SaveCapturedVariables(path, Variables{"token": "def"})
+	assert.Eq(t, nil, err)
+
+	variables, err := LoadCapturedVariables(path)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "def", variables["token"])
+	assert.Eq(t, "line1\nline2", variables["body"])
+}
@@ -0,0 +1,201 @@
+// Package config loads the project configuration from the restree.toml file at the root of the tree
+//
+//	env = "staging"
+//	expand_body_variables = true
+//	verbose = true
+//
+//	[tls]
+//	insecure_skip_verify = true
+//	ca_cert = "certs/ca.pem"
+//
+//	[http]
+//	timeout = "30s"
+//	proxy = "http://localhost:8080"
+//
+//	[scripts]
+//	shell = "/bin/bash"
+//	timeout = "10s"
+//	cache = false
+//
+//	[build]
+//	format = "curl"
+//
+//	[test]
+//	reporter = "junit"
+//	ignore = ["drafts", "*.slow.http"]
+//
+// The file is parsed as a subset of TOML, one statement per line:
+//
+//   - comments starting with # outside of strings
+//   - [table] headers with a bare name, array tables [[table]] are not supported
+//   - key = value pairs with a bare key, quoted and dotted keys are not supported
+//   - basic strings with Go escapes, literal strings, true, false and decimal integers
+//   - arrays of those values on a single line
+//
+// Multi-line strings and arrays, inline tables, floats and dates are rejected.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// FileName is the name of the config file, it also marks the root of the tree
+const FileName = "restree.toml"
+
+type Config struct {
+	// Env is the default environment profile
+	Env                 string
+	ExpandBodyVariables bool
+	Verbose             bool
+	TLS                 TLS
+	HTTP                HTTP
+	Scripts             Scripts
+	Build               Build
+	Test                Test
+}
+
+type TLS struct {
+	InsecureSkipVerify bool
+	// CACert is the absolute path of a PEM file with additional trusted certificates
+	CACert string
+}
+
+type HTTP struct {
+	Timeout time.Duration
+	Proxy   string
+}
+
+type Scripts struct {
+	// Shell runs the scripts without a shebang line
+	Shell   string
+	Timeout time.Duration
+	// NoCache disables the cache of script outputs
+	NoCache bool
+}
+
+type Build struct {
+	// Format is the output format of the build subcommand
+	Format string
+}
+
+type Test struct {
+	Reporter string
+	// Ignore are the path patterns skipped by the test subcommand
+	Ignore []string
+}
+
+// Load loads the config file of the root directory, a missing file results in an empty config
+func Load(root string) (*Config, error) {
+	path := filepath.Join(root, FileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	cfg, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	if cfg.TLS.CACert != "" && !filepath.IsAbs(cfg.TLS.CACert) {
+		cfg.TLS.CACert = filepath.Join(root, cfg.TLS.CACert)
+	}
+	return cfg, nil
+}
+
+// Parse parses the content of a config file
+func Parse(data string) (*Config, error) {
+	values, err := parseTOML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		value := values[key]
+		var err error
+		switch key {
+		case "env":
+			err = assign(&cfg.Env, value)
+		case "expand_body_variables":
+			err = assign(&cfg.ExpandBodyVariables, value)
+		case "verbose":
+			err = assign(&cfg.Verbose, value)
+		case "tls.insecure_skip_verify":
+			err = assign(&cfg.TLS.InsecureSkipVerify, value)
+		case "tls.ca_cert":
+			err = assign(&cfg.TLS.CACert, value)
+		case "http.timeout":
+			err = assignDuration(&cfg.HTTP.Timeout, value)
+		case "http.proxy":
+			err = assign(&cfg.HTTP.Proxy, value)
+		case "scripts.shell":
+			err = assign(&cfg.Scripts.Shell, value)
+		case "scripts.timeout":
+			err = assignDuration(&cfg.Scripts.Timeout, value)
+		case "scripts.cache":
+			var cache bool
+			err = assign(&cache, value)
+			cfg.Scripts.NoCache = !cache
+		case "build.format":
+			err = assign(&cfg.Build.Format, value)
+		case "test.reporter":
+			err = assign(&cfg.Test.Reporter, value)
+		case "test.ignore":
+			err = assignStrings(&cfg.Test.Ignore, value)
+		default:
+			err = errors.New("unknown key")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func assign[T any](dst *T, value any) error {
+	v, ok := value.(T)
+	if !ok {
+		return fmt.Errorf("expected %T, got %v", *dst, value)
+	}
+	*dst = v
+	return nil
+}
+
+func assignDuration(dst *time.Duration, value any) error {
+	var s string
+	if err := assign(&s, value); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*dst = d
+	return nil
+}
+
+func assignStrings(dst *[]string, value any) error {
+	items, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("expected array, got %v", value)
+	}
+	for _, item := range items {
+		var s string
+		if err := assign(&s, item); err != nil {
+			return err
+		}
+		*dst = append(*dst, s)
+	}
+	return nil
+}
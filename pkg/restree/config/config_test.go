@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func validConfig() string {
+	return `# project defaults
+env = "staging"
+expand_body_variables = true
+
+[tls]
+insecure_skip_verify = true # self-signed
+ca_cert = 'certs/ca.pem'
+
+[http]
+timeout = "30s"
+proxy = "http://localhost:8080"
+
+[scripts]
+shell = "/bin/bash"
+timeout = "10s"
+cache = false
+
+[test]
+reporter = "junit"
+ignore = ["drafts", "*.slow.http", "#not-a-comment"]
+`
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(validConfig())
+	assert.Eq(t, nil, err)
+
+	assert.Eq(t, "staging", cfg.Env)
+	assert.Eq(t, true, cfg.ExpandBodyVariables)
+	assert.Eq(t, false, cfg.Verbose)
+	assert.Eq(t, true, cfg.TLS.InsecureSkipVerify)
+	assert.Eq(t, "certs/ca.pem", cfg.TLS.CACert)
+	assert.Eq(t, 30*time.Second, cfg.HTTP.Timeout)
+	assert.Eq(t, "http://localhost:8080", cfg.HTTP.Proxy)
+	assert.Eq(t, "/bin/bash", cfg.Scripts.Shell)
+	assert.Eq(t, 10*time.Second, cfg.Scripts.Timeout)
+	assert.Eq(t, true, cfg.Scripts.NoCache)
+	assert.Eq(t, "junit", cfg.Test.Reporter)
+	assert.Eq(t, 3, len(cfg.Test.Ignore))
+	assert.Eq(t, "#not-a-comment", cfg.Test.Ignore[2])
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"unknown = true",
+		"env = staging",
+		"env = true",
+		"[http]\ntimeout = \"soon\"",
+		"[tls\ninsecure_skip_verify = true",
+		"env = \"a\"\nenv = \"b\"",
+		"[test]\nignore = [1, 2]",
+		"[test]\nignore = [\n  \"drafts\",\n]",
+		"http = { timeout = \"30s\" }",
+		"[[test]]",
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt)
+		assert.Neq(t, nil, err)
+	}
+}
+
+func TestParseEscapedQuotes(t *testing.T) {
+	cfg, err := Parse(`env = "a\\" # b"
+[test]
+ignore = ["x\"#,y", 'c:\', "z"] # comment
+`)
+	assert.Eq(t, nil, err)
+
+	assert.Eq(t, `a\`, cfg.Env)
+	assert.Eq(t, 3, len(cfg.Test.Ignore))
+	assert.Eq(t, `x"#,y`, cfg.Test.Ignore[0])
+	assert.Eq(t, `c:\`, cfg.Test.Ignore[1])
+}
+
+func TestParseFirstErrorSorted(t *testing.T) {
+	for range 10 {
+		_, err := Parse("verbose = 1\nenv = 1\nzzz = true")
+		assert.Eq(t, "env: expected string, got 1", err.Error())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := Load(dir)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "", cfg.Env)
+
+	assert.Eq(t, nil, os.WriteFile(filepath.Join(dir, FileName), []byte(validConfig()), 0o600))
+	cfg, err = Load(dir)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, filepath.Join(dir, "certs", "ca.pem"), cfg.TLS.CACert)
+}
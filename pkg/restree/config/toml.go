@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML used by the config file into a map of dotted keys
+//
+// The supported subset is described in the package documentation.
+func parseTOML(data string) (map[string]any, error) {
+	values := map[string]any{}
+	table := ""
+
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name, ok := strings.CutSuffix(strings.TrimPrefix(line, "["), "]")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" || strings.HasPrefix(name, "[") {
+				return nil, fmt.Errorf("line %d: invalid table %q", i+1, line)
+			}
+			table = name + "."
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t\"'") {
+			return nil, fmt.Errorf("line %d: invalid line %q", i+1, line)
+		}
+
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", i+1, key, err)
+		}
+
+		if _, ok := values[table+key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %s", i+1, table+key)
+		}
+		values[table+key] = value
+	}
+
+	return values, nil
+}
+
+func parseValue(raw string) (any, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("missing value")
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case raw[0] == '"':
+		return strconv.Unquote(raw)
+	case raw[0] == '\'':
+		s, ok := strings.CutSuffix(raw[1:], "'")
+		if !ok || strings.Contains(s, "'") {
+			return nil, fmt.Errorf("invalid literal string %s", raw)
+		}
+		return s, nil
+	case raw[0] == '[':
+		inner, ok := strings.CutSuffix(raw[1:], "]")
+		if !ok {
+			return nil, fmt.Errorf("invalid array %s", raw)
+		}
+		items := []any{}
+		for _, item := range splitArray(inner) {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			v, err := parseValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	default:
+		n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s", raw)
+		}
+		return n, nil
+	}
+}
+
+// stripComment removes the comment outside of strings from the line
+func stripComment(line string) string {
+	var q quoteState
+	for i, r := range line {
+		if !q.next(r) && r == '#' {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitArray splits the array items on the commas outside of strings
+func splitArray(s string) []string {
+	items := []string{}
+	var q quoteState
+	start := 0
+	for i, r := range s {
+		if !q.next(r) && r == ',' {
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+// quoteState tracks whether a scan is inside a basic or a literal string
+//
+// Backslash escapes are only recognized in basic strings, literal strings end at the next single quote.
+type quoteState struct {
+	quote   rune
+	escaped bool
+}
+
+// next advances the state past r and reports whether r is part of a string
+func (q *quoteState) next(r rune) bool {
+	switch {
+	case q.quote == 0 && (r == '"' || r == '\''):
+		q.quote = r
+	case q.quote == 0:
+		return false
+	case q.escaped:
+		q.escaped = false
+	case q.quote == '"' && r == '\\':
+		q.escaped = true
+	case r == q.quote:
+		q.quote = 0
+	}
+	return true
+}
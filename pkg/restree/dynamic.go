@@ -0,0 +1,216 @@
+package restree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Dynamic generates values of the built-in dynamic variables
+//
+//	{{$uuid}}                      random UUID v4
+//	{{$timestamp}}                 current unix timestamp in seconds
+//	{{$isoTimestamp}}              current time in RFC 3339 format, UTC
+//	{{$isoDate}}                   current date in YYYY-MM-DD format, UTC
+//	{{$randomInt min max}}         random integer in [min, max), defaults to [0, 1000)
+//	{{$randomHex n}}               n random hexadecimal characters, defaults to 16
+//	{{$date "layout" offset}}      current time in the Go time layout shifted by an optional offset, e.g. -1d, +2h30m
+//
+// Every occurrence is evaluated separately.
+type Dynamic struct {
+	rand *rand.Rand
+	now  func() time.Time
+}
+
+// NewDynamic returns a generator with the seeded random number generator and the clock
+func NewDynamic(seed uint64, now func() time.Time) *Dynamic {
+	return &Dynamic{
+		rand: rand.New(rand.NewPCG(seed, seed)),
+		now:  now,
+	}
+}
+
+var dynamic = NewDynamic(rand.Uint64(), time.Now)
+
+// SetDynamic replaces the generator used for dynamic variables, e.g. to make the output reproducible
+func SetDynamic(d *Dynamic) {
+	dynamic = d
+}
+
+// Evaluate evaluates the dynamic variable expression without the leading $
+func (d *Dynamic) Evaluate(expr string) (string, error) {
+	args, err := splitArgs(expr)
+	if err != nil {
+		return "", fmt.Errorf("$%s: %w", expr, err)
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty dynamic variable")
+	}
+	name, args := args[0], args[1:]
+
+	switch name {
+	case "uuid":
+		return d.uuid(), nil
+	case "timestamp":
+		return strconv.FormatInt(d.now().Unix(), 10), nil
+	case "isoTimestamp":
+		return d.now().UTC().Format(time.RFC3339), nil
+	case "isoDate":
+		return d.now().UTC().Format(time.DateOnly), nil
+	case "randomInt":
+		return d.randomInt(args)
+	case "randomHex":
+		return d.randomHex(args)
+	case "date":
+		return d.date(args)
+	default:
+		return "", fmt.Errorf("unknown dynamic variable: $%s", name)
+	}
+}
+
+// Boundary returns a random multipart boundary
+func (d *Dynamic) Boundary() string {
+	boundary, _ := d.randomHex([]string{"32"})
+	return boundary
+}
+
+func (d *Dynamic) uuid() string {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(d.rand.UintN(256))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func (d *Dynamic) randomInt(args []string) (string, error) {
+	var lo, hi int64 = 0, 1000
+	switch len(args) {
+	case 0:
+	case 2:
+		var errLo, errHi error
+		lo, errLo = strconv.ParseInt(args[0], 10, 64)
+		hi, errHi = strconv.ParseInt(args[1], 10, 64)
+		if errLo != nil || errHi != nil {
+			return "", fmt.Errorf("$randomInt: invalid range %s %s", args[0], args[1])
+		}
+	default:
+		return "", fmt.Errorf("$randomInt: expected no arguments or min and max")
+	}
+	if hi <= lo {
+		return "", fmt.Errorf("$randomInt: max must be greater than min")
+	}
+	// the span of the widest ranges only fits in uint64, the sum wraps back into the range
+	span := uint64(hi) - uint64(lo)
+	return strconv.FormatInt(int64(uint64(lo)+d.rand.Uint64N(span)), 10), nil
+}
+
+func (d *Dynamic) randomHex(args []string) (string, error) {
+	n := 16
+	if len(args) > 1 {
+		return "", fmt.Errorf("$randomHex: expected an optional length")
+	}
+	if len(args) == 1 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("$randomHex: invalid length %s", args[0])
+		}
+	}
+
+	const digits = "0123456789abcdef"
+	var b strings.Builder
+	for range n {
+		b.WriteByte(digits[d.rand.IntN(len(digits))])
+	}
+	return b.String(), nil
+}
+
+func (d *Dynamic) date(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("$date: expected layout and an optional offset")
+	}
+	t := d.now()
+	if len(args) == 2 {
+		offset, err := parseOffset(args[1])
+		if err != nil {
+			return "", fmt.Errorf("$date: %w", err)
+		}
+		t = t.Add(offset)
+	}
+	return t.Format(args[0]), nil
+}
+
+// parseOffset parses [time.ParseDuration] durations extended with days, e.g. -1d or +1d12h
+func parseOffset(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	rest := s
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		sign, rest = -1, rest[1:]
+	case strings.HasPrefix(rest, "+"):
+		rest = rest[1:]
+	}
+
+	var days time.Duration
+	if idx := strings.Index(rest, "d"); idx != -1 {
+		n, err := strconv.Atoi(rest[:idx])
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset: %s", s)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		rest = rest[idx+1:]
+	}
+
+	var d time.Duration
+	if rest != "" {
+		var err error
+		d, err = time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset: %s", s)
+		}
+	}
+
+	return sign * (days + d), nil
+}
+
+// splitArgs splits s by whitespace keeping "quoted" and 'quoted' arguments together
+func splitArgs(s string) ([]string, error) {
+	args := []string{}
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
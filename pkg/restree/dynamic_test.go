@@ -0,0 +1,63 @@
+package restree
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func validNow() time.Time {
+	return time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+}
+
+func TestDynamicEvaluate(t *testing.T) {
+	d := NewDynamic(42, validNow)
+
+	tests := []struct {
+		expr    string
+		pattern string
+		wantErr bool
+	}{
+		{"uuid", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, false},
+		{"timestamp", `^1710498600$`, false},
+		{"isoTimestamp", `^2024-03-15T10:30:00Z$`, false},
+		{"isoDate", `^2024-03-15$`, false},
+		{"randomInt 1 3", `^[12]$`, false},
+		{"randomInt", `^\d{1,3}$`, false},
+		{"randomHex 8", `^[0-9a-f]{8}$`, false},
+		{`date "2006-01-02" -1d`, `^2024-03-14$`, false},
+		{`date "15:04" +1h30m`, `^12:00$`, false},
+		{"randomInt -5000000000000000000 5000000000000000000", `^-?\d+$`, false},
+		{"randomInt -9223372036854775808 9223372036854775807", `^-?\d+$`, false},
+		{"randomInt 5 1", "", true},
+		{"randomInt 1 9223372036854775808", "", true},
+		{"date", "", true},
+		{"unknown", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := d.Evaluate(tt.expr)
+		assert.Eq(t, tt.wantErr, err != nil)
+		if err == nil {
+			assert.Assert(t, regexp.MustCompile(tt.pattern).MatchString(got), tt.expr+": "+got)
+		}
+	}
+}
+
+func TestDynamicSeedReproducible(t *testing.T) {
+	a, _ := NewDynamic(7, validNow).Evaluate("uuid")
+	b, _ := NewDynamic(7, validNow).Evaluate("uuid")
+	assert.Eq(t, a, b)
+}
+
+func TestExpandVariablesDynamic(t *testing.T) {
+	previous := dynamic
+	SetDynamic(NewDynamic(1, validNow))
+	t.Cleanup(func() { SetDynamic(previous) })
+
+	got, err := expandVariables("{{$isoDate}} {{missing || $timestamp}}", Variables{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "2024-03-15 1710498600", got)
+}
@@ -0,0 +1,167 @@
+package restree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// DotEnvFileName is the env file for local, usually ignored, variables
+	DotEnvFileName = ".env"
+	// VarsFileName is the env file for variables shared with the tree
+	VarsFileName = "_vars.env"
+)
+
+// VarsFileNames are the env files loaded in every directory of the tree, in order
+var VarsFileNames = []string{DotEnvFileName, VarsFileName}
+
+// EnvDirName is the directory containing environment profiles, e.g. `_env/staging.env`
+const EnvDirName = "_env"
+
+// directivePrefix starts comment directives in env files and scripts, e.g. `# restree: confirm`
+const directivePrefix = "# restree:"
+
+// EnvFile is a parsed environment file
+type EnvFile struct {
+	Variables Variables
+	// Confirm requires a confirmation before sending requests that are not read-only
+	Confirm bool
+}
+
+// ParseEnvFile parses environment file in the dotenv format
+// environment file structure
+//
+// # restree: confirm
+// # comment
+// <KEY>=<VALUE> # inline comment
+// export <KEY>=<VALUE>
+// <KEY>='<literal value>'
+// <KEY>="<value with \n escapes
+// that can span multiple lines>"
+// ...
+func ParseEnvFile(r io.Reader) (*EnvFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &EnvFile{
+		Variables: make(Variables),
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if directives, ok := strings.CutPrefix(line, directivePrefix); ok {
+			for _, d := range strings.Fields(directives) {
+				if d == "confirm" {
+					env.Confirm = true
+				}
+			}
+			continue
+		}
+
+		// skip comments and empty lines
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid line: %q", i+1, lines[i])
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			env.Variables[key] = unquotedEnvValue(value)
+			continue
+		}
+
+		// quoted values may span multiple lines
+		quote := value[0]
+		start := i
+		raw := value[1:]
+		end := closingQuote(raw, quote)
+		for end == -1 && i+1 < len(lines) {
+			i++
+			raw += "\n" + lines[i]
+			end = closingQuote(raw, quote)
+		}
+		if end == -1 {
+			return nil, fmt.Errorf("line %d: unterminated quoted value for %s", start+1, key)
+		}
+
+		rest := strings.TrimSpace(raw[end+1:])
+		if rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected characters after quoted value for %s", i+1, key)
+		}
+
+		raw = raw[:end]
+		if quote == '"' {
+			raw = unescapeEnvValue(raw)
+		}
+		env.Variables[key] = raw
+	}
+
+	return env, nil
+}
+
+// unquotedEnvValue strips inline comments and surrounding whitespace
+func unquotedEnvValue(value string) string {
+	if idx := strings.Index(value, " #"); idx != -1 {
+		value = value[:idx]
+	}
+	return strings.TrimSpace(value)
+}
+
+// closingQuote returns the index of the closing quote in s or -1
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeEnvValue(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// IsReadOnlyMethod reports whether the HTTP method does not modify the server state
+func IsReadOnlyMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,45 @@
+package restree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	b := "# restree: confirm\n# comment\n\nhost=http://localhost\ntoken = \"abc\"\nname='John'\n"
+	env, err := ParseEnvFile(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, true, env.Confirm)
+	assert.Eq(t, 3, len(env.Variables))
+	assert.Eq(t, "http://localhost", env.Variables["host"])
+	assert.Eq(t, "abc", env.Variables["token"])
+	assert.Eq(t, "John", env.Variables["name"])
+
+	_, err = ParseEnvFile(bytes.NewBufferString("invalid"))
+	assert.Neq(t, nil, err)
+}
+
+func TestParseEnvFileDotenv(t *testing.T) {
+	b := `export host=http://localhost # local server
+empty=
+escaped="line1\nline2 \"quoted\""
+multiline="first
+second"
+literal='no \n escapes
+here' # comment
+hash=a#b
+`
+	env, err := ParseEnvFile(bytes.NewBufferString(b))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "http://localhost", env.Variables["host"])
+	assert.Eq(t, "", env.Variables["empty"])
+	assert.Eq(t, "line1\nline2 \"quoted\"", env.Variables["escaped"])
+	assert.Eq(t, "first\nsecond", env.Variables["multiline"])
+	assert.Eq(t, "no \\n escapes\nhere", env.Variables["literal"])
+	assert.Eq(t, "a#b", env.Variables["hash"])
+
+	_, err = ParseEnvFile(bytes.NewBufferString("unterminated=\"value\n"))
+	assert.Neq(t, nil, err)
+}
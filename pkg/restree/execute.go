@@ -0,0 +1,83 @@
+package restree
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// Doer sends HTTP requests, it is satisfied by [http.Client]
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Result is the outcome of [Execute]
+type Result struct {
+	Response *http.Response
+	// Body is the already read response body
+	Body     []byte
+	Duration time.Duration
+	// Captured are the variables captured from the response
+	Captured   Variables
+	Assertions []AssertionResult
+}
+
+// Failed returns the number of failed assertions
+func (r *Result) Failed() int {
+	failed := 0
+	for _, a := range r.Assertions {
+		if !a.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Execute sends the request, reads the response and evaluates its assertions and captures
+//
+// When a capture fails the result is returned with the error.
+func Execute(client Doer, httpFile *httpparser.HTTPRequest) (*Result, error) {
+	var bodyReader io.Reader
+	if httpFile.Body != "" {
+		bodyReader = strings.NewReader(httpFile.Body)
+	}
+	req, err := http.NewRequest(httpFile.Method, httpFile.URL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	for _, header := range httpFile.Headers {
+		req.Header.Add(header.Name, header.Value)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error occured during request: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	result := &Result{
+		Response: resp,
+		Body:     b,
+		Duration: time.Since(start),
+	}
+
+	// the assertions are reported even when a capture fails
+	result.Assertions = CheckAssertions(httpFile.Assertions, resp, b, result.Duration)
+
+	result.Captured, err = Capture(httpFile.Captures, resp, b)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
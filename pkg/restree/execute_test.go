@@ -0,0 +1,40 @@
+package restree
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestExecuteAssertionsBeforeCaptures(t *testing.T) {
+	client := doerFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"id": 1}`)),
+			Request:    req,
+		}, nil
+	})
+	req := &httpparser.HTTPRequest{
+		Method:     "GET",
+		URL:        "http://localhost/",
+		Captures:   []httpparser.Capture{{Name: "token", Source: "$.token"}},
+		Assertions: []httpparser.Assertion{{Subject: "status", Operator: "==", Expected: "201"}},
+	}
+
+	result, err := Execute(client, req)
+	assert.Neq(t, nil, err)
+	assert.Neq(t, nil, result)
+	assert.Eq(t, 1, len(result.Assertions))
+	assert.Eq(t, 1, result.Failed())
+}
@@ -0,0 +1,190 @@
+package restree
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var placeholderRegexp = regexp.MustCompile(`\{\{(.+?)\}\}`)
+
+var (
+	identifierExprRegexp = regexp.MustCompile(`^\w+$`)
+	fallbackExprRegexp   = regexp.MustCompile(`^\w+\s*:[-?]`)
+	dynamicExprRegexp    = regexp.MustCompile(`^\$\w+(\s|$)`)
+)
+
+// isExpression reports whether the content of {{...}} is a placeholder expression
+//
+// Other text in braces, e.g. {{.Name}} or {{#each items}} of Go and handlebars templates, is left as is.
+func isExpression(expr string) bool {
+	pipeline := splitPipeline(expr)
+	for _, fn := range pipeline[1:] {
+		if !identifierExprRegexp.MatchString(strings.TrimSpace(fn)) {
+			return false
+		}
+	}
+
+	alternatives := splitAlternatives(pipeline[0])
+	for i, alt := range alternatives {
+		alt = strings.TrimSpace(alt)
+		if _, ok := unquoteLiteral(alt); ok || identifierExprRegexp.MatchString(alt) || dynamicExprRegexp.MatchString(alt) {
+			continue
+		}
+		// fallbacks only apply to the last alternative
+		if i == len(alternatives)-1 && fallbackExprRegexp.MatchString(alt) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// errMissingVariable is returned by evaluateExpression when the variable is not set
+type errMissingVariable struct {
+	Name string
+}
+
+func (e *errMissingVariable) Error() string {
+	return fmt.Sprintf("missing variable: %s", e.Name)
+}
+
+// expandVariables replaces all occurrences of variables in the `{{var}}` format
+//
+// Each variable placeholder in the content (e.g., "{{name}}") is replaced with
+// the corresponding value from the `variables` map (e.g., variables["name"]).
+//
+// Placeholders support shell-like fallbacks:
+//
+//	{{name:-default}}         default when name is unset or empty
+//	{{name:?message}}         fail with message when name is unset or empty
+//	{{first || second}}       first of the alternatives that is set and not empty
+//	{{first || "literal"}}    alternatives can be quoted literals
+//
+// Placeholders starting with $ are dynamic variables, see [Dynamic].
+//
+// Values can be transformed with pipelines of [Functions], e.g. {{user | base64}}
+// or {{body | sha256 | hex}}.
+//
+// Example:
+//
+//	variables := Variables{
+//	    "test": "world",
+//	}
+//	content := "hello {{test}} {{page:-1}}"
+//	result, err := expandVariables(content, variables)
+//	// result == "hello world 1"
+func expandVariables(content string, variables Variables) (string, error) {
+	var missingVariables []string
+	var errs []error
+
+	output := placeholderRegexp.ReplaceAllStringFunc(content, func(match string) string {
+		expr := placeholderRegexp.FindStringSubmatch(match)[1]
+		if !isExpression(expr) {
+			return match
+		}
+
+		val, err := evaluateExpression(expr, variables)
+		if err != nil {
+			var missing *errMissingVariable
+			if errors.As(err, &missing) {
+				missingVariables = append(missingVariables, missing.Name)
+			} else {
+				errs = append(errs, err)
+			}
+			return match
+		}
+
+		return val
+	})
+
+	if len(missingVariables) != 0 {
+		errs = append(errs, fmt.Errorf("missing variables: %s", missingVariables))
+	}
+
+	return output, errors.Join(errs...)
+}
+
+// evaluateExpression evaluates the content of a single placeholder
+func evaluateExpression(expr string, variables Variables) (string, error) {
+	pipeline := splitPipeline(expr)
+
+	value, err := evaluateValue(pipeline[0], variables)
+	if err != nil {
+		return "", err
+	}
+
+	functions := make([]string, 0, len(pipeline)-1)
+	for _, fn := range pipeline[1:] {
+		functions = append(functions, strings.TrimSpace(fn))
+	}
+	return applyPipeline(value, functions)
+}
+
+// evaluateValue evaluates the placeholder value without the pipeline
+func evaluateValue(expr string, variables Variables) (string, error) {
+	alternatives := splitAlternatives(expr)
+
+	// all alternatives but the last one fall through when not set
+	for _, alt := range alternatives[:len(alternatives)-1] {
+		alt = strings.TrimSpace(alt)
+		if literal, ok := unquoteLiteral(alt); ok {
+			return literal, nil
+		}
+		if expr, ok := strings.CutPrefix(alt, "$"); ok {
+			return dynamic.Evaluate(expr)
+		}
+		if val := variables[alt]; val != "" {
+			return val, nil
+		}
+	}
+
+	last := strings.TrimSpace(alternatives[len(alternatives)-1])
+	if literal, ok := unquoteLiteral(last); ok {
+		return literal, nil
+	}
+	if expr, ok := strings.CutPrefix(last, "$"); ok {
+		return dynamic.Evaluate(expr)
+	}
+
+	if name, def, found := strings.Cut(last, ":-"); found {
+		if val := variables[strings.TrimSpace(name)]; val != "" {
+			return val, nil
+		}
+		return strings.TrimSpace(def), nil
+	}
+
+	if name, msg, found := strings.Cut(last, ":?"); found {
+		name = strings.TrimSpace(name)
+		if val := variables[name]; val != "" {
+			return val, nil
+		}
+		msg = strings.TrimSpace(msg)
+		if msg == "" {
+			msg = "required variable is not set"
+		}
+		return "", fmt.Errorf("%s: %s", name, msg)
+	}
+
+	if val, ok := variables[last]; ok {
+		return val, nil
+	}
+	return "", &errMissingVariable{Name: last}
+}
+
+// unquoteLiteral unquotes "literal" and 'literal' strings
+func unquoteLiteral(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '"' && s[0] != '\'') || s[len(s)-1] != s[0] {
+		return "", false
+	}
+	if s[0] == '\'' {
+		return s[1 : len(s)-1], true
+	}
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		return "", false
+	}
+	return unquoted, true
+}
@@ -0,0 +1,92 @@
+package restree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func TestExpandVariables(t *testing.T) {
+	variables := map[string]string{
+		"name":  "world",
+		"foo":   "bar",
+		"empty": "",
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"hello {{name}}", "hello world", false},
+		{"foo {{foo}} baz", "foo bar baz", false},
+		{"missing {{missing}}", "missing {{missing}}", true},
+		{"no vars here", "no vars here", false},
+		{"spaces {{ name }}", "spaces world", false},
+		{"page={{page:-1}}", "page=1", false},
+		{"empty={{empty:-default}}", "empty=default", false},
+		{"set={{name:-default}}", "set=world", false},
+		{"page={{page:- 1}}", "page=1", false},
+		{"{{x:-a|b}}", "a|b", false},
+		{"{{x:-a||b}}", "a||b", false},
+		{"{{x:-O'Brien}}", "O'Brien", false},
+		{"{{token:?run login.http}}", "{{token:?run login.http}}", true},
+		{"{{name:?run login.http}}", "world", false},
+		{"{{missing || foo}}", "bar", false},
+		{"{{empty || missing || name}}", "world", false},
+		{"{{missing || \"literal\"}}", "literal", false},
+		{"{{missing || other:-fallback}}", "fallback", false},
+		{"{{missing || other}}", "{{missing || other}}", true},
+		{"{{missing || \"x||y\"}}", "x||y", false},
+		{"{{missing || 'a | b'}}", "a | b", false},
+		{`{"tpl": "{{.Name}}"}`, `{"tpl": "{{.Name}}"}`, false},
+		{"{{#each items}}{{name}}{{/each}}", "{{#each items}}world{{/each}}", false},
+		{"{{name | nope-nope}}", "{{name | nope-nope}}", false},
+	}
+
+	for _, tt := range tests {
+		got, err := expandVariables(tt.input, variables)
+		assert.Eq(t, (err != nil), tt.wantErr)
+		assert.Eq(t, got, tt.expected)
+	}
+}
+
+func TestExpandVariablesRequiredMessage(t *testing.T) {
+	_, err := expandVariables("{{token:?token is required, run login.http}}", Variables{})
+	assert.Neq(t, nil, err)
+	assert.Assert(t, strings.Contains(err.Error(), "token: token is required, run login.http"), err.Error())
+}
+
+func TestExpandVariablesPipeline(t *testing.T) {
+	variables := Variables{
+		"user":  "username:password",
+		"name":  "John Doe",
+		"id":    "abc",
+		"empty": "",
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"{{user | base64}}", "dXNlcm5hbWU6cGFzc3dvcmQ=", false},
+		{"{{user | basicauth}}", "Basic dXNlcm5hbWU6cGFzc3dvcmQ=", false},
+		{"{{name | urlencode}}", "John+Doe", false},
+		{"{{name | pathencode}}", "John%20Doe", false},
+		{"{{id | upper}}", "ABC", false},
+		{"{{id | sha256 | hex}}", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", false},
+		{"{{empty || name | lower}}", "john doe", false},
+		{`{{missing || "a|b" | upper}}`, "A|B", false},
+		{"{{id | unknown}}", "{{id | unknown}}", true},
+		{"{{missing:-a|b | upper}}", "A|B", false},
+		{"{{missing:-O'Brien | lower}}", "o'brien", false},
+	}
+
+	for _, tt := range tests {
+		got, err := expandVariables(tt.input, variables)
+		assert.Eq(t, tt.wantErr, err != nil)
+		assert.Eq(t, tt.expected, got)
+	}
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// Curl renders the request as a curl command
+//
+// Multipart bodies are sent with --form, letting curl generate the boundary.
+// HEAD requests use -I, with -X HEAD curl would wait for a response body.
+func Curl(req *httpparser.HTTPRequest) (string, error) {
+	cmd := &command{}
+	hasBody := len(req.Parts) > 0 || req.BodyFile != "" || req.Body != ""
+	switch {
+	case req.Method == "HEAD":
+		cmd.add("curl", "-I", req.URL)
+	case req.Method == "GET" && !hasBody:
+		cmd.add("curl", req.URL)
+	default:
+		// curl sends POST when data is given without -X
+		cmd.add("curl", "-X", req.Method, req.URL)
+	}
+
+	multipart := len(req.Parts) > 0
+	for _, h := range headers(req, multipart) {
+		if h.Value == "" {
+			// curl drops headers with an empty value unless they end with a semicolon
+			cmd.add("-H", h.Name+";")
+			continue
+		}
+		cmd.add("-H", h.Name+": "+h.Value)
+	}
+
+	switch {
+	case multipart:
+		for _, part := range req.Parts {
+			if part.File == "" {
+				cmd.add("--form-string", part.Name+"="+part.Value)
+				continue
+			}
+			form := part.Name + "=@" + curlFormValue(part.File)
+			if part.Filename != "" {
+				form += ";filename=" + curlFormValue(part.Filename)
+			}
+			if part.ContentType != "" {
+				form += ";type=" + part.ContentType
+			}
+			cmd.add("-F", form)
+		}
+	case hasFileBody(req):
+		cmd.add("--data-binary", "@"+req.BodyFile)
+	case req.Body != "":
+		cmd.add("--data-raw", req.Body)
+	}
+
+	return cmd.String(), nil
+}
+
+// curlFormValue quotes the value of a --form field when it contains separators
+func curlFormValue(s string) string {
+	if !strings.ContainsAny(s, `;,"`) {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,95 @@
+// Package export renders built requests as shell commands of other HTTP clients
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// Exporter renders the request as a shell command
+//
+// The request is expected to be built, with absolute BodyFile and part File paths.
+// An error is returned when the client cannot send the request.
+type Exporter func(req *httpparser.HTTPRequest) (string, error)
+
+var exporters = map[string]Exporter{
+	"curl":   Curl,
+	"httpie": HTTPie,
+	"wget":   Wget,
+}
+
+// Names returns the names of available exporters
+func Names() []string {
+	names := []string{}
+	for name := range exporters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New returns the exporter with the given name
+func New(name string) (Exporter, error) {
+	e, ok := exporters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, available: %s", name, strings.Join(Names(), ", "))
+	}
+	return e, nil
+}
+
+var safeWordRegexp = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote quotes the string as a single POSIX shell word
+func Quote(s string) string {
+	if safeWordRegexp.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// command joins the arguments of a command, putting every option on its own line
+type command struct {
+	lines []string
+}
+
+func (c *command) add(words ...string) {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		quoted = append(quoted, Quote(w))
+	}
+	c.lines = append(c.lines, strings.Join(quoted, " "))
+}
+
+// raw adds shell syntax, e.g. a redirection, that must not be quoted
+func (c *command) raw(s string) {
+	c.lines = append(c.lines, s)
+}
+
+func (c *command) String() string {
+	return strings.Join(c.lines, " \\\n  ")
+}
+
+// hasFileBody reports whether the body should be read from the file instead of being inlined
+//
+// Expanded body files differ from the file content, so they are inlined.
+func hasFileBody(req *httpparser.HTTPRequest) bool {
+	return req.BodyFile != "" && !req.ExpandBodyFile
+}
+
+// headers returns the headers of the request, without the Content-Type of multipart bodies generated by the client
+func headers(req *httpparser.HTTPRequest, multipart bool) httpparser.HTTPHeaders {
+	if !multipart {
+		return req.Headers
+	}
+	result := httpparser.HTTPHeaders{}
+	for _, h := range req.Headers {
+		if !strings.EqualFold(h.Name, "Content-Type") {
+			result = append(result, h)
+		}
+	}
+	return result
+}
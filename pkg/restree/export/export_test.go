@@ -0,0 +1,134 @@
+package export
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+func validRequest() *httpparser.HTTPRequest {
+	return &httpparser.HTTPRequest{
+		Method: "POST",
+		URL:    "http://localhost/users?active=true&sort=name",
+		Headers: httpparser.HTTPHeaders{
+			{Name: "Content-Type", Value: "application/json"},
+			{Name: "X-Empty", Value: ""},
+		},
+		Body: `{"name": "O'Brien"}`,
+	}
+}
+
+func validMultipartRequest() *httpparser.HTTPRequest {
+	return &httpparser.HTTPRequest{
+		Method: "POST",
+		URL:    "http://localhost/upload",
+		Headers: httpparser.HTTPHeaders{
+			{Name: "Content-Type", Value: "multipart/form-data; boundary=abc"},
+		},
+		Parts: []httpparser.Part{
+			{Name: "title", Value: "@not a file"},
+			{Name: "avatar", File: "/tmp/my avatar.png", Filename: "me;1.png", ContentType: "image/png"},
+		},
+		Body: "--abc\r\n...",
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []string{"plain", "", "with space", "it's", `"double" $HOME \n`, "multi\nline", "--flag=a,b"}
+
+	for _, tt := range tests {
+		out, err := exec.Command("/bin/sh", "-c", "printf %s "+Quote(tt)).Output()
+		assert.Eq(t, nil, err)
+		assert.Eq(t, tt, string(out))
+	}
+}
+
+func TestCurl(t *testing.T) {
+	assert.Eq(t, `curl -X POST 'http://localhost/users?active=true&sort=name' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Empty;' \
+  --data-raw '{"name": "O'\''Brien"}'`, mustExport(t, Curl, validRequest()))
+
+	assert.Eq(t, `curl -X POST http://localhost/upload \
+  --form-string 'title=@not a file' \
+  -F 'avatar=@/tmp/my avatar.png;filename="me;1.png";type=image/png'`, mustExport(t, Curl, validMultipartRequest()))
+
+	assert.Eq(t, `curl http://localhost/`, mustExport(t, Curl, &httpparser.HTTPRequest{Method: "GET", URL: "http://localhost/"}))
+}
+
+func TestCurlBodyFile(t *testing.T) {
+	req := &httpparser.HTTPRequest{Method: "PUT", URL: "http://localhost/", BodyFile: "/data/body.json", Body: "{}"}
+	assert.Eq(t, `curl -X PUT http://localhost/ \
+  --data-binary @/data/body.json`, mustExport(t, Curl, req))
+
+	req.ExpandBodyFile = true
+	assert.Eq(t, `curl -X PUT http://localhost/ \
+  --data-raw '{}'`, mustExport(t, Curl, req))
+}
+
+func TestHTTPie(t *testing.T) {
+	assert.Eq(t, `http --ignore-stdin POST 'http://localhost/users?active=true&sort=name' \
+  Content-Type:application/json \
+  'X-Empty;' \
+  --raw '{"name": "O'\''Brien"}'`, mustExport(t, HTTPie, validRequest()))
+
+	req := validMultipartRequest()
+	req.Parts[1].Filename = "my avatar.png"
+	assert.Eq(t, `http --ignore-stdin POST http://localhost/upload \
+  --multipart \
+  'title=@not a file' \
+  'avatar@/tmp/my avatar.png;type=image/png'`, mustExport(t, HTTPie, req))
+
+	req = &httpparser.HTTPRequest{Method: "PUT", URL: "http://localhost/", BodyFile: "/data/body.json"}
+	assert.Eq(t, `http PUT http://localhost/ \
+  < /data/body.json`, mustExport(t, HTTPie, req))
+}
+
+func TestWget(t *testing.T) {
+	assert.Eq(t, `wget -q -O - --method=POST \
+  '--header=Content-Type: application/json' \
+  '--header=X-Empty: ' \
+  '--body-data={"name": "O'\''Brien"}' \
+  'http://localhost/users?active=true&sort=name'`, mustExport(t, Wget, validRequest()))
+
+	req := &httpparser.HTTPRequest{Method: "PUT", URL: "http://localhost/", BodyFile: "/data/body.json"}
+	assert.Eq(t, `wget -q -O - --method=PUT \
+  --body-file=/data/body.json \
+  http://localhost/`, mustExport(t, Wget, req))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("curl")
+	assert.Eq(t, nil, err)
+
+	_, err = New("postman")
+	assert.Neq(t, nil, err)
+}
+
+func mustExport(t *testing.T, e Exporter, req *httpparser.HTTPRequest) string {
+	t.Helper()
+	command, err := e(req)
+	assert.Eq(t, nil, err)
+	return command
+}
+
+func TestCurlMethod(t *testing.T) {
+	req := &httpparser.HTTPRequest{Method: "GET", URL: "http://localhost/search", Body: `{"q": "a"}`}
+	assert.Eq(t, `curl -X GET http://localhost/search \
+  --data-raw '{"q": "a"}'`, mustExport(t, Curl, req))
+
+	req = &httpparser.HTTPRequest{Method: "HEAD", URL: "http://localhost/"}
+	assert.Eq(t, `curl -I http://localhost/`, mustExport(t, Curl, req))
+}
+
+func TestWgetMultipart(t *testing.T) {
+	_, err := Wget(validMultipartRequest())
+	assert.Neq(t, nil, err)
+}
+
+func TestHTTPieFilename(t *testing.T) {
+	_, err := HTTPie(validMultipartRequest())
+	assert.Neq(t, nil, err)
+}
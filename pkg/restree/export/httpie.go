@@ -0,0 +1,61 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// HTTPie renders the request as an HTTPie command
+//
+// Body files are redirected to stdin, multipart file parts use the file name of the path.
+// HTTPie can not rename uploaded files, so file parts with another filename are rejected.
+func HTTPie(req *httpparser.HTTPRequest) (string, error) {
+	for _, part := range req.Parts {
+		if part.File != "" && part.Filename != "" && part.Filename != filepath.Base(part.File) {
+			return "", fmt.Errorf("httpie does not support the filename %q of part %s, use curl", part.Filename, part.Name)
+		}
+	}
+
+	cmd := &command{}
+
+	multipart := len(req.Parts) > 0
+	if hasFileBody(req) {
+		cmd.add("http", req.Method, req.URL)
+	} else {
+		cmd.add("http", "--ignore-stdin", req.Method, req.URL)
+	}
+	if multipart {
+		cmd.add("--multipart")
+	}
+
+	for _, h := range headers(req, multipart) {
+		if h.Value == "" {
+			cmd.add(h.Name + ";")
+			continue
+		}
+		cmd.add(h.Name + ":" + h.Value)
+	}
+
+	switch {
+	case multipart:
+		for _, part := range req.Parts {
+			if part.File == "" {
+				cmd.add(part.Name + "=" + part.Value)
+				continue
+			}
+			item := part.Name + "@" + part.File
+			if part.ContentType != "" {
+				item += ";type=" + part.ContentType
+			}
+			cmd.add(item)
+		}
+	case hasFileBody(req):
+		cmd.raw("< " + Quote(req.BodyFile))
+	case req.Body != "":
+		cmd.add("--raw", req.Body)
+	}
+
+	return cmd.String(), nil
+}
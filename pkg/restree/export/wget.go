@@ -0,0 +1,33 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// Wget renders the request as a wget command printing the response to stdout
+//
+// wget cannot build multipart bodies, so multipart requests are rejected.
+func Wget(req *httpparser.HTTPRequest) (string, error) {
+	if len(req.Parts) > 0 {
+		return "", fmt.Errorf("wget does not support multipart bodies, use curl or httpie")
+	}
+
+	cmd := &command{}
+	cmd.add("wget", "-q", "-O", "-", "--method="+req.Method)
+
+	for _, h := range req.Headers {
+		cmd.add("--header=" + h.Name + ": " + h.Value)
+	}
+
+	switch {
+	case hasFileBody(req):
+		cmd.add("--body-file=" + req.BodyFile)
+	case req.Body != "":
+		cmd.add("--body-data=" + req.Body)
+	}
+
+	cmd.add(req.URL)
+	return cmd.String(), nil
+}
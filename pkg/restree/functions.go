@@ -0,0 +1,133 @@
+package restree
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Functions are the functions available in placeholder pipelines, e.g. `{{user | base64}}`
+//
+//	upper       upper case
+//	lower       lower case
+//	trim        strip surrounding whitespace
+//	base64      standard base64 encoding
+//	base64url   URL-safe base64 encoding without padding
+//	hex         hexadecimal encoding
+//	urlencode   query escaping, spaces become +
+//	pathencode  path segment escaping, spaces become %20
+//	json        JSON string escaping without the surrounding quotes
+//	sha256      raw SHA-256 digest, combine with hex or base64
+//	sha1        raw SHA-1 digest, combine with hex or base64
+//	md5         raw MD5 digest, combine with hex or base64
+//	basicauth   "Basic " followed by base64 of the value in the user:password format
+var Functions = map[string]func(string) string{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"base64":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"base64url":  func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) },
+	"hex":        func(s string) string { return hex.EncodeToString([]byte(s)) },
+	"urlencode":  url.QueryEscape,
+	"pathencode": url.PathEscape,
+	"json": func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b[1 : len(b)-1])
+	},
+	"sha256": func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return string(sum[:])
+	},
+	"sha1": func(s string) string {
+		sum := sha1.Sum([]byte(s))
+		return string(sum[:])
+	},
+	"md5": func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return string(sum[:])
+	},
+	"basicauth": func(s string) string { return "Basic " + base64.StdEncoding.EncodeToString([]byte(s)) },
+}
+
+// applyPipeline passes the value through the functions in order
+func applyPipeline(value string, functions []string) (string, error) {
+	for _, name := range functions {
+		fn, ok := Functions[name]
+		if !ok {
+			return "", fmt.Errorf("unknown function %q, available: %s", name, strings.Join(functionNames(), ", "))
+		}
+		value = fn(value)
+	}
+	return value, nil
+}
+
+func functionNames() []string {
+	names := []string{}
+	for name := range Functions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// splitPipeline splits the expression by single | ignoring || and quoted strings
+//
+// Within the default of a :- or :? fallback only | surrounded by spaces starts the pipeline.
+func splitPipeline(expr string) []string {
+	return splitUnquoted(expr, "|")
+}
+
+// splitAlternatives splits the expression by || ignoring quoted strings and fallback defaults
+func splitAlternatives(expr string) []string {
+	return splitUnquoted(expr, "||")
+}
+
+// splitUnquoted splits the expression by the | or || operator outside of quoted strings
+//
+// The text after a :- or :? fallback operator is taken literally, it is only split by " | ".
+func splitUnquoted(expr string, op string) []string {
+	parts := []string{}
+	start := 0
+	var quote byte
+	fallback := false
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case fallback:
+			if op == "|" && c == '|' && i > 0 && expr[i-1] == ' ' && i+1 < len(expr) && expr[i+1] == ' ' {
+				parts = append(parts, expr[start:i])
+				start = i + 1
+			}
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && i+1 < len(expr) && (expr[i+1] == '-' || expr[i+1] == '?'):
+			fallback = true
+			i++
+		case c == '|' && i+1 < len(expr) && expr[i+1] == '|':
+			if op == "||" {
+				parts = append(parts, expr[start:i])
+				start = i + 2
+			}
+			i++
+		case c == '|' && op == "|":
+			parts = append(parts, expr[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, expr[start:])
+}
@@ -1,17 +1,17 @@
 package restree
 
 import (
-	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"maps"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/kamil-koziol/restree/pkg/httpparser"
 )
@@ -19,6 +19,7 @@ import (
 const (
 	HeadersFileName      = "_headers.http"
 	BeforeScriptFileName = "_before.sh"
+	AfterScriptFileName  = "_after.sh"
 )
 
 type Variables map[string]string
@@ -26,8 +27,11 @@ type Variables map[string]string
 // ExpandHTTPRequest expands HTTP request with provided variables
 func ExpandHTTPRequest(req *httpparser.HTTPRequest, variables Variables, expandBodyVariables bool) (*httpparser.HTTPRequest, error) {
 	result := &httpparser.HTTPRequest{
-		Method:  req.Method,
-		Headers: httpparser.HTTPHeaders{},
+		Name:     req.Name,
+		Method:   req.Method,
+		Headers:  httpparser.HTTPHeaders{},
+		Captures: req.Captures,
+		Tags:     req.Tags,
 	}
 
 	// Expand URL
@@ -38,12 +42,47 @@ func ExpandHTTPRequest(req *httpparser.HTTPRequest, variables Variables, expandB
 	result.URL = u
 
 	// Expand headers
-	for h, v := range req.Headers {
-		eh, err := expandVariables(v, variables)
+	for _, h := range req.Headers {
+		eh, err := expandVariables(h.Value, variables)
 		if err != nil {
-			return nil, fmt.Errorf("unable to expand header: %s: %s: %w", h, v, err)
+			return nil, fmt.Errorf("unable to expand header: %s: %s: %w", h.Name, h.Value, err)
 		}
-		result.Headers[h] = eh
+		h.Value = eh
+		result.Headers = append(result.Headers, h)
+	}
+
+	// Expand body file path
+	result.BodyFile, err = expandVariables(req.BodyFile, variables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to expand body file: %w", err)
+	}
+	result.ExpandBodyFile = req.ExpandBodyFile
+
+	// Expand parts
+	for _, part := range req.Parts {
+		for _, field := range []*string{&part.Name, &part.Filename, &part.ContentType, &part.File} {
+			*field, err = expandVariables(*field, variables)
+			if err != nil {
+				return nil, fmt.Errorf("unable to expand part %s: %w", part.Name, err)
+			}
+		}
+		if expandBodyVariables {
+			part.Value, err = expandVariables(part.Value, variables)
+			if err != nil {
+				return nil, fmt.Errorf("unable to expand part %s: %w", part.Name, err)
+			}
+		}
+		result.Parts = append(result.Parts, part)
+	}
+
+	// Expand assertions
+	for _, a := range req.Assertions {
+		expected, err := expandVariables(a.Expected, variables)
+		if err != nil {
+			return nil, fmt.Errorf("unable to expand assertion: %s: %w", a, err)
+		}
+		a.Expected = expected
+		result.Assertions = append(result.Assertions, a)
 	}
 
 	// Expand body
@@ -60,12 +99,28 @@ func ExpandHTTPRequest(req *httpparser.HTTPRequest, variables Variables, expandB
 }
 
 // ReadHTTPRequest reads [httpparser.HTTPRequest] from data and expands it with provided variables
-func ReadHTTPRequest(data io.Reader, variables Variables, expandBodyVariables bool) (*httpparser.HTTPRequest, error) {
-	httpRequest, err := httpparser.Parse(data)
+//
+// When data contains multiple requests, selector picks one of them by name or 1-based index.
+// An empty selector picks the first request.
+// The inherited headers of the tree decide, merged with the headers of the request, whether the body is multipart.
+func ReadHTTPRequest(data io.Reader, selector string, inherited httpparser.HTTPHeaders, variables Variables, expandBodyVariables bool) (*httpparser.HTTPRequest, error) {
+	httpRequests, err := httpparser.ParseAll(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse: %s", err)
 	}
 
+	httpRequest := httpRequests[0]
+	if selector != "" {
+		httpRequest, err = httpparser.Select(httpRequests, selector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := httpRequest.ParseMultipart(inherited.Merge(httpRequest.Headers)); err != nil {
+		return nil, fmt.Errorf("failed to parse: %s", err)
+	}
+
 	expandedHTTPRequest, err := ExpandHTTPRequest(httpRequest, variables, expandBodyVariables)
 	if err != nil {
 		return nil, fmt.Errorf("unable to expand http request: %w", err)
@@ -87,87 +142,59 @@ func ReadHTTPHeaders(data io.Reader, variables Variables) (httpparser.HTTPHeader
 
 	// Expand headers
 	result := httpparser.HTTPHeaders{}
-	for h, v := range parsed {
-		eh, err := expandVariables(v, variables)
+	for _, h := range parsed {
+		eh, err := expandVariables(h.Value, variables)
 		if err != nil {
-			return nil, fmt.Errorf("unable to expand header: %s: %s: %w", h, v, err)
+			return nil, fmt.Errorf("unable to expand header: %s: %s: %w", h.Name, h.Value, err)
 		}
-		result[h] = eh
+		h.Value = eh
+		result = append(result, h)
 	}
 
 	return result, nil
 }
 
-func runScriptFromFS(fsys fs.FS, scriptPath string) (string, string, error) {
-	file, err := fsys.Open(scriptPath)
-	if err != nil {
-		return "", "", fmt.Errorf("cannot open script: %w", err)
-	}
-	defer file.Close() //nolint:errcheck
-
-	scriptData, err := io.ReadAll(file)
-	if err != nil {
-		return "", "", fmt.Errorf("cannot read script: %w", err)
+// readEnvFileFS reads the env file, a missing file results in nil
+func readEnvFileFS(fsys fs.FS, path string) (*EnvFile, error) {
+	f, err := fsys.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
 	}
-
-	tmpFile, err := os.CreateTemp("", "script-*.sh")
 	if err != nil {
-		return "", "", fmt.Errorf("cannot create temp file: %w", err)
-	}
-	defer os.Remove(tmpFile.Name()) //nolint:errcheck
-	defer tmpFile.Close()           //nolint:errcheck
-
-	if _, err := tmpFile.Write(scriptData); err != nil {
-		return "", "", fmt.Errorf("cannot write temp script: %w", err)
-	}
-
-	if err := os.Chmod(tmpFile.Name(), 0o700); err != nil {
-		return "", "", fmt.Errorf("cannot chmod temp script: %w", err)
+		return nil, fmt.Errorf("unable to open env file: %w", err)
 	}
+	defer f.Close() //nolint:errcheck
 
-	cmd := exec.Command("/bin/sh", tmpFile.Name())
-	var stdoutBuf, stderrBuf bytes.Buffer
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
-
-	err = cmd.Run()
+	envFile, err := ParseEnvFile(f)
 	if err != nil {
-		return "", "", fmt.Errorf("script execution error: %w", err)
+		return nil, fmt.Errorf("failed to parse env file %s: %s", path, err)
 	}
-
-	return stdoutBuf.String(), stderrBuf.String(), nil
+	return envFile, nil
 }
 
-// parseScriptEnvOutput parses the output as env
-func parseScriptEnvOutput(out string) (Variables, error) {
-	envMap := make(Variables)
-	scanner := bufio.NewScanner(bytes.NewBufferString(out))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				key := parts[0]
-				value := parts[1]
-				envMap[key] = value
-			}
-		}
-	}
-
-	return envMap, nil
+// directory is the result of processing a single directory of the tree
+type directory struct {
+	Headers httpparser.HTTPHeaders
+	// EnvFound is set when the directory contains the selected environment profile
+	EnvFound bool
+	// Confirm is set when the environment profile requires confirmation
+	Confirm bool
 }
 
-func processDirectoryFS(fsys fs.FS, currentPath string, variables Variables) (httpparser.HTTPHeaders, error) {
+func processDirectoryFS(fsys fs.FS, currentPath string, variables Variables, opts RecursiveReadOpts, ctx scriptContext) (*directory, error) {
 	entries, err := fs.ReadDir(fsys, currentPath)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read dir %s: %w", currentPath, err)
 	}
 
-	var headersFile, beforeScriptFile fs.DirEntry
+	var headersFile, beforeScriptFile, envDir fs.DirEntry
 
 	// find the files in directory
 	for _, entry := range entries {
 		if entry.IsDir() {
+			if entry.Name() == EnvDirName {
+				envDir = entry
+			}
 			continue
 		}
 
@@ -179,12 +206,49 @@ func processDirectoryFS(fsys fs.FS, currentPath string, variables Variables) (ht
 		}
 	}
 
-	// run the before script first
-	// TODO: pass the current headers to the scripts as a variable
+	result := &directory{}
+
+	// load the env files first
+	for _, name := range VarsFileNames {
+		envFile, err := readEnvFileFS(fsys, filepath.Join(currentPath, name))
+		if err != nil {
+			return nil, err
+		}
+		if envFile != nil {
+			envVariables := opts.withoutCaptured(envFile.Variables)
+			maps.Copy(variables, envVariables)
+			opts.Trace.AddVariables(filepath.Join(currentPath, name), envVariables)
+		}
+	}
+
+	// then the environment profile
+	if opts.Env != "" && envDir != nil {
+		envPath := filepath.Join(currentPath, envDir.Name(), opts.Env+".env")
+		envFile, err := readEnvFileFS(fsys, envPath)
+		if err != nil {
+			return nil, err
+		}
+		if envFile != nil {
+			envVariables := opts.withoutCaptured(envFile.Variables)
+			maps.Copy(variables, envVariables)
+			opts.Trace.AddVariables(envPath, envVariables)
+			result.EnvFound = true
+			result.Confirm = envFile.Confirm
+		}
+	}
+
+	// run the before script
 	if beforeScriptFile != nil {
-		stdout, stderr, err := runScriptFromFS(fsys, filepath.Join(currentPath, beforeScriptFile.Name()))
+		scriptPath := filepath.Join(currentPath, beforeScriptFile.Name())
+		env, cleanup, err := ctx.environ(variables)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+
+		stdout, cached, err := runCachedScript(filepath.Join(ctx.Root, scriptPath), env, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to execute before script: %s\n%s", err, stderr)
+			return nil, fmt.Errorf("failed to execute before script %s: %w", scriptPath, err)
 		}
 		exportedEnvs, err := parseScriptEnvOutput(stdout)
 		if err != nil {
@@ -193,10 +257,15 @@ func processDirectoryFS(fsys fs.FS, currentPath string, variables Variables) (ht
 
 		// set the variables
 		maps.Copy(variables, exportedEnvs)
+		if cached {
+			opts.Trace.AddVariables(scriptPath+" (cached)", exportedEnvs)
+		} else {
+			opts.Trace.AddVariables(scriptPath, exportedEnvs)
+		}
 	}
 
 	// run parse the headers
-	headers := httpparser.HTTPHeaders{}
+	result.Headers = httpparser.HTTPHeaders{}
 	if headersFile != nil {
 		headersPath := filepath.Join(currentPath, headersFile.Name())
 		f, err := fsys.Open(headersPath)
@@ -205,86 +274,203 @@ func processDirectoryFS(fsys fs.FS, currentPath string, variables Variables) (ht
 		}
 		defer f.Close() //nolint:errcheck
 
-		headers, err = ReadHTTPHeaders(f, variables)
+		data, err := opts.Trace.referenceReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read headers file: %w", err)
+		}
+		result.Headers, err = ReadHTTPHeaders(data, variables)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load template %s: %s", headersPath, err)
 		}
+		for i := range result.Headers {
+			result.Headers[i].File = headersPath
+		}
 	}
 
-	return headers, nil
+	return result, nil
+}
+
+// readBodyFileFS reads the body file referenced from a .http file in dir
+//
+// Relative paths are resolved against dir through fsys, absolute paths are read from the disk.
+// It returns the absolute path of the file and its content.
+func readBodyFileFS(fsys fs.FS, root string, dir string, bodyFile string) (string, string, error) {
+	if filepath.IsAbs(bodyFile) {
+		b, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read body file: %w", err)
+		}
+		return bodyFile, string(b), nil
+	}
+
+	p := path.Join(filepath.ToSlash(dir), filepath.ToSlash(bodyFile))
+	b, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read body file %s: %w", bodyFile, err)
+	}
+	return filepath.Join(root, filepath.FromSlash(p)), string(b), nil
 }
 
 type RecursiveReadOpts struct {
 	ExpandBodyVariables bool
+	// Request selects the request by name or 1-based index when the target file contains multiple requests
+	Request string
+	// Env selects the environment profile loaded from `_env/<Env>.env` files
+	Env string
+	// Confirm is called before returning a request that is not read-only when the environment profile requires confirmation.
+	// The request is rejected when it returns false. When nil no confirmation is asked.
+	Confirm func(req *httpparser.HTTPRequest) bool
+	// Trace records the provenance of headers and variables when not nil
+	Trace *Trace
+	// ScriptStdin is forwarded to the scripts, e.g. to prompt for MFA codes. When nil the scripts read from the null device.
+	ScriptStdin io.Reader
+	// ScriptCache stores the output of scripts declaring `# restree: cache=<duration>`. When nil scripts always run.
+	ScriptCache *ScriptCache
+	// ScriptShell runs the scripts without a shebang line, /bin/sh when empty
+	ScriptShell string
+	// ScriptTimeout interrupts scripts running longer than it. When zero scripts are not interrupted.
+	ScriptTimeout time.Duration
+	// Captured are the variables captured from previous responses, env files and profiles do not override them
+	Captured Variables
 }
 
-func RecursiveReadFS(fsys fs.FS, from string, to string, variables Variables, opts RecursiveReadOpts) (*httpparser.HTTPRequest, error) {
-	traversalStr, found := strings.CutPrefix(to, from)
-	if !found {
-		return nil, fmt.Errorf("%s must be under %s", to, from)
+// withoutCaptured returns the variables of an env file without the captured ones
+func (opts RecursiveReadOpts) withoutCaptured(variables Variables) Variables {
+	if len(opts.Captured) == 0 {
+		return variables
+	}
+	result := Variables{}
+	for name, value := range variables {
+		if _, ok := opts.Captured[name]; !ok {
+			result[name] = value
+		}
 	}
+	return result
+}
 
-	traversal := strings.Split(traversalStr, string(os.PathSeparator))
+// treeLevels returns the directories from the root to the directory of the target, relative to the root
+func treeLevels(from string, to string) ([]string, error) {
+	rel, err := filepath.Rel(from, to)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("%s is outside of the root directory %s", to, from)
+	}
 
-	dirs := traversal[:len(traversal)-1]
+	levels := []string{"."}
+	currentPath := "."
+	for _, dir := range strings.Split(filepath.Dir(rel), string(os.PathSeparator)) {
+		if dir == "." {
+			continue
+		}
+		currentPath = filepath.Join(currentPath, dir)
+		levels = append(levels, currentPath)
+	}
+	return levels, nil
+}
+
+// SplitTarget splits target in the `path#request` format into the file path and the request selector
+//
+// Example:
+//
+//	path, selector := SplitTarget("users/crud.http#create-user")
+//	// path == "users/crud.http", selector == "create-user"
+func SplitTarget(target string) (string, string) {
+	idx := strings.LastIndex(target, "#")
+	if idx == -1 || strings.ContainsRune(target[idx:], os.PathSeparator) {
+		return target, ""
+	}
+	return target[:idx], target[idx+1:]
+}
+
+func RecursiveReadFS(fsys fs.FS, from string, to string, variables Variables, opts RecursiveReadOpts) (*httpparser.HTTPRequest, error) {
+	levels, err := treeLevels(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// the target is read upfront to pass the request to the scripts
+	targetData, err := os.ReadFile(to)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open target file: %w", err)
+	}
+	ctx := scriptContext{
+		Root:    from,
+		Target:  to,
+		Request: opts.Request,
+	}
+	if requests, err := httpparser.ParseAll(bytes.NewReader(targetData)); err == nil {
+		req := requests[0]
+		if opts.Request != "" {
+			req, _ = httpparser.Select(requests, opts.Request)
+		}
+		if req != nil {
+			ctx.Method = req.Method
+			ctx.URL = req.URL
+			opts.Trace.Reference(req.Source())
+		}
+	}
 
 	headers := httpparser.HTTPHeaders{}
+	envFound := false
+	confirm := false
 
 	currentPath := "."
-	for _, dir := range dirs {
-		currentPath = filepath.Join(currentPath, dir)
-		directoryHeaders, err := processDirectoryFS(fsys, currentPath, variables)
+	for _, currentPath = range levels {
+		ctx.Dir = filepath.Join(from, currentPath)
+		ctx.Headers = headers
+		directory, err := processDirectoryFS(fsys, currentPath, variables, opts, ctx)
 		if err != nil {
 			return nil, fmt.Errorf("unable to process dir: %s", err)
 		}
-		maps.Copy(headers, directoryHeaders)
+		headers = opts.Trace.mergeHeaders(headers, directory.Headers)
+		envFound = envFound || directory.EnvFound
+		confirm = confirm || directory.Confirm
 	}
 
-	f, err := os.Open(to)
-	if err != nil {
-		return nil, fmt.Errorf("unable to open target file: %w", err)
+	if opts.Env != "" && !envFound {
+		return nil, fmt.Errorf("environment %q not found, expected %s/%s.env in the tree", opts.Env, EnvDirName, opts.Env)
 	}
-	defer f.Close() //nolint:errcheck
 
-	httpFile, err := ReadHTTPRequest(f, variables, opts.ExpandBodyVariables)
+	httpFile, err := ReadHTTPRequest(bytes.NewReader(targetData), opts.Request, headers, variables, opts.ExpandBodyVariables)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load file %s: %s", to, err)
 	}
 
-	maps.Copy(httpFile.Headers, headers)
-	return httpFile, nil
-}
+	// the request file is the closest level, so its headers win
+	for i := range httpFile.Headers {
+		httpFile.Headers[i].File = filepath.Join(currentPath, filepath.Base(to))
+	}
+	httpFile.Headers = opts.Trace.mergeHeaders(headers, httpFile.Headers)
 
-// expandVariables replaces all occurrences of variables in the `{{var}}` format
-//
-// Each variable placeholder in the content (e.g., "{{name}}") is replaced with
-// the corresponding value from the `variables` map (e.g., variables["name"]).
-//
-// Example:
-//
-//	variables := Variables{
-//	    "test": "world",
-//	}
-//	content := "hello {{test}}"
-//	result, err := expandVariables(content, variables)
-//	// result == "hello world"
-func expandVariables(content string, variables Variables) (string, error) {
-	re := regexp.MustCompile(`\{\{(\w+)\}\}`)
-
-	var missingVariables []string
-	output := re.ReplaceAllStringFunc(content, func(match string) string {
-		key := re.FindStringSubmatch(match)[1]
-		if val, ok := variables[key]; ok {
-			return val
+	if httpFile.BodyFile != "" {
+		bodyPath, body, err := readBodyFileFS(fsys, from, currentPath, httpFile.BodyFile)
+		if err != nil {
+			return nil, err
+		}
+		if httpFile.ExpandBodyFile {
+			opts.Trace.Reference(body)
+			body, err = expandVariables(body, variables)
+			if err != nil {
+				return nil, fmt.Errorf("unable to expand body file %s: %w", httpFile.BodyFile, err)
+			}
 		}
+		httpFile.BodyFile = bodyPath
+		httpFile.Body = body
+	}
 
-		missingVariables = append(missingVariables, key)
-		return match
-	})
+	if len(httpFile.Parts) > 0 {
+		contentType, body, err := encodeMultipart(fsys, from, currentPath, httpFile.Parts)
+		if err != nil {
+			return nil, err
+		}
+		httpFile.Headers = opts.Trace.mergeHeaders(httpFile.Headers, httpparser.HTTPHeaders{{Name: "Content-Type", Value: contentType, File: "multipart body"}})
+		httpFile.Body = body
+	}
 
-	if len(missingVariables) != 0 {
-		return output, fmt.Errorf("missing variables: %s", missingVariables)
+	if confirm && opts.Confirm != nil && !IsReadOnlyMethod(httpFile.Method) {
+		if !opts.Confirm(httpFile) {
+			return nil, fmt.Errorf("%s %s was not confirmed in %q environment", httpFile.Method, httpFile.URL, opts.Env)
+		}
 	}
 
-	return output, nil
+	return httpFile, nil
 }
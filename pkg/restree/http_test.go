@@ -1,9 +1,17 @@
 package restree
 
 import (
+	"maps"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
 )
 
 func TestParseScriptEnvOutput(t *testing.T) {
@@ -24,26 +32,248 @@ func TestParseScriptEnvOutput(t *testing.T) {
 	assert.Assert(t, !ok, "expected INVALID_LINE to be ignored")
 }
 
-func TestExpandVariables(t *testing.T) {
-	variables := map[string]string{
-		"name": "world",
-		"foo":  "bar",
-	}
-
+func TestSplitTarget(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected string
-		wantErr  bool
+		path     string
+		selector string
 	}{
-		{"hello {{name}}", "hello world", false},
-		{"foo {{foo}} baz", "foo bar baz", false},
-		{"missing {{missing}}", "missing {{missing}}", true},
-		{"no vars here", "no vars here", false},
+		{"users/crud.http", "users/crud.http", ""},
+		{"users/crud.http#create-user", "users/crud.http", "create-user"},
+		{"users/crud.http#2", "users/crud.http", "2"},
+		{"we#ird/crud.http", "we#ird/crud.http", ""},
 	}
 
 	for _, tt := range tests {
-		got, err := expandVariables(tt.input, variables)
-		assert.Eq(t, (err != nil), tt.wantErr)
-		assert.Eq(t, got, tt.expected)
+		path, selector := SplitTarget(tt.input)
+		assert.Eq(t, tt.path, path)
+		assert.Eq(t, tt.selector, selector)
 	}
 }
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Eq(t, nil, os.MkdirAll(filepath.Dir(p), 0o755))
+		assert.Eq(t, nil, os.WriteFile(p, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestRecursiveReadFSEnv(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"_env/prod.env":       "# restree: confirm\nhost=https://prod\nuser=root\n",
+		"users/_env/prod.env": "user=john\n",
+		"users/create.http":   "POST {{host}}/users/{{user}}\n",
+	})
+	target := filepath.Join(dir, "users", "create.http")
+
+	confirmed := 0
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, target, Variables{}, RecursiveReadOpts{
+		Env: "prod",
+		Confirm: func(req *httpparser.HTTPRequest) bool {
+			confirmed++
+			return true
+		},
+	})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 1, confirmed)
+	assert.Eq(t, "https://prod/users/john", req.URL)
+
+	_, err = RecursiveReadFS(os.DirFS(dir), dir, target, Variables{}, RecursiveReadOpts{
+		Env:     "prod",
+		Confirm: func(req *httpparser.HTTPRequest) bool { return false },
+	})
+	assert.Neq(t, nil, err)
+
+	_, err = RecursiveReadFS(os.DirFS(dir), dir, target, Variables{}, RecursiveReadOpts{Env: "dev"})
+	assert.Neq(t, nil, err)
+}
+
+func TestRecursiveReadFSVarsFiles(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".env":            "host=http://localhost\nversion=v1\n",
+		"users/_vars.env": "version=v2\n",
+		"users/get.http":  "GET {{host}}/{{version}}/users\n",
+	})
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "get.http"), Variables{}, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "http://localhost/v2/users", req.URL)
+}
+
+func TestRecursiveReadFSCapturedPrecedence(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".env":             "token=static\nuser=static\nid=static\n",
+		"_env/dev.env":     "token=dev\n",
+		"users/_vars.env":  "user=vars\n",
+		"users/_before.sh": "echo id=script\n",
+		"users/get.http":   "GET http://localhost/{{token}}/{{user}}/{{id}}\n",
+	})
+	captured := Variables{"token": "captured", "user": "captured", "id": "captured"}
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "get.http"), maps.Clone(captured), RecursiveReadOpts{
+		Env:      "dev",
+		Captured: captured,
+	})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "http://localhost/captured/captured/script", req.URL)
+}
+
+func TestRecursiveReadFSBodyFile(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"payloads/user.json": `{"name": "{{name}}"}`,
+		"users/create.http":  "POST http://localhost/users\n\n< ../payloads/user.json\n",
+		"users/update.http":  "PUT http://localhost/users\n\n<@ ../payloads/user.json\n",
+	})
+	variables := Variables{"name": "John"}
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "create.http"), variables, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, `{"name": "{{name}}"}`, req.Body)
+	assert.Eq(t, filepath.Join(dir, "payloads", "user.json"), req.BodyFile)
+
+	req, err = RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "update.http"), variables, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, `{"name": "John"}`, req.Body)
+}
+
+func TestRecursiveReadFSMultipart(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"users/avatar.png":  "PNG",
+		"users/upload.http": "POST http://localhost/avatars\nContent-Type: multipart/form-data\n\n--- description\n{{name}}\n--- avatar\n< ./avatar.png\n",
+	})
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "upload.http"), Variables{"name": "John"}, RecursiveReadOpts{
+		ExpandBodyVariables: true,
+	})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 2, len(req.Parts))
+	assert.Eq(t, "avatar.png", req.Parts[1].Filename)
+	assert.Eq(t, "image/png", req.Parts[1].ContentType)
+
+	_, params, err := mime.ParseMediaType(req.Headers.Values("Content-Type")[0])
+	assert.Eq(t, nil, err)
+	form, err := multipart.NewReader(strings.NewReader(req.Body), params["boundary"]).ReadForm(1 << 20)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "John", form.Value["description"][0])
+	assert.Eq(t, "avatar.png", form.File["avatar"][0].Filename)
+}
+
+func TestRecursiveReadFSMultipartInherited(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"upload/" + HeadersFileName: "Content-Type: multipart/form-data\n",
+		"upload/a.txt":              "A",
+		"upload/file.http":          "POST http://localhost/files\n\n--- file filename=\"a\\\\b\tc.txt\"\n< ./a.txt\n",
+	})
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "upload", "file.http"), Variables{}, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 1, len(req.Parts))
+
+	_, params, err := mime.ParseMediaType(req.Headers.Values("Content-Type")[0])
+	assert.Eq(t, nil, err)
+	form, err := multipart.NewReader(strings.NewReader(req.Body), params["boundary"]).ReadForm(1 << 20)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "a\\\\b\tc.txt", form.File["file"][0].Filename)
+}
+
+func TestRecursiveReadFSHeadersOrder(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		HeadersFileName:            "Accept: */*\nX-Forwarded-For: 10.0.0.1\nX-Forwarded-For: 10.0.0.2\n",
+		"users/" + HeadersFileName: "X-Users: true\n",
+		"users/get.http":           "GET http://localhost/users\nCookie: a=1\nCookie: b=2\n",
+	})
+
+	for range 5 {
+		req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "get.http"), Variables{}, RecursiveReadOpts{})
+		assert.Eq(t, nil, err)
+		assert.Eq(t, "GET http://localhost/users\nAccept: */*\nX-Forwarded-For: 10.0.0.1\nX-Forwarded-For: 10.0.0.2\nX-Users: true\nCookie: a=1\nCookie: b=2\n", req.String())
+	}
+}
+
+func TestRecursiveReadFSHeadersPrecedence(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		HeadersFileName:             "Authorization: Bearer token\nAccept: application/json\nX-Client: root\n",
+		"public/" + HeadersFileName: "-Authorization\n+Accept: text/csv\n",
+		"public/list.http":          "GET http://localhost/public\nX-Client: request\n",
+	})
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "public", "list.http"), Variables{}, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, false, req.Headers.Has("Authorization"))
+	assert.Eq(t, "GET http://localhost/public\nAccept: application/json\nAccept: text/csv\nX-Client: request\n", req.String())
+}
+
+func TestRecursiveReadFSBeforeScriptContext(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		HeadersFileName: "Accept: application/json\n+Accept: text/csv\n",
+		".env":          "token=abc\n",
+		"users/_before.sh": `echo "method=$RESTREE_METHOD"
+echo "url=$RESTREE_URL"
+echo "accept=$RESTREE_HEADER_ACCEPT"
+echo "seen=$token"
+echo "dir=$(basename "$RESTREE_DIR")"
+echo "headers=$(cat "$RESTREE_HEADERS_FILE")"
+`,
+		"users/get.http": "GET {{host}}/users\n\n### create\nPOST {{host}}/users\n",
+	})
+
+	trace := &Trace{}
+	_, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "get.http"), Variables{"host": "http://localhost"}, RecursiveReadOpts{
+		Request: "create",
+		Trace:   trace,
+	})
+	assert.Eq(t, nil, err)
+
+	got := map[string]string{}
+	for _, v := range trace.Variables() {
+		got[v.Name] = v.Value
+	}
+	assert.Eq(t, "POST", got["method"])
+	assert.Eq(t, "{{host}}/users", got["url"])
+	assert.Eq(t, "application/json, text/csv", got["accept"])
+	assert.Eq(t, "abc", got["seen"])
+	assert.Eq(t, "users", got["dir"])
+	assert.Eq(t, `[{"name":"Accept","value":"application/json"},{"name":"Accept","value":"text/csv"}]`, got["headers"])
+}
+
+func TestRecursiveReadFSBeforeScriptInPlace(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"users/_before.sh": "#!/usr/bin/env -S awk -f\nBEGIN { getline token < \"token.txt\"; print \"token=\" token }\n",
+		"users/token.txt":  "abc\n",
+		"users/get.http":   "GET http://localhost/users\nAuthorization: Bearer {{token}}\n",
+	})
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users", "get.http"), Variables{}, RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "Bearer abc", req.Headers.Values("Authorization")[0])
+}
+
+func TestRecursiveReadFSBeforeScriptStdin(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		BeforeScriptFileName: "read code\necho \"code=$code\"\n",
+		"get.http":           "GET http://localhost/\nX-MFA: {{code}}\n",
+	})
+
+	req, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "get.http"), Variables{}, RecursiveReadOpts{
+		ScriptStdin: strings.NewReader("123456\n"),
+	})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "123456", req.Headers.Values("X-MFA")[0])
+}
+
+func TestRecursiveReadFSBeforeScriptTimeout(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		BeforeScriptFileName: "exec sleep 10\n",
+		"get.http":           "GET http://localhost/\n",
+	})
+
+	_, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "get.http"), Variables{}, RecursiveReadOpts{
+		ScriptTimeout: 100 * time.Millisecond,
+	})
+	assert.Neq(t, nil, err)
+	assert.Assert(t, strings.Contains(err.Error(), "timed out after 100ms"), "unexpected error: "+err.Error())
+}
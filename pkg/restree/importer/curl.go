@@ -0,0 +1,314 @@
+// Package importer converts requests of other tools into .http files
+package importer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// curlShortFlags maps the short curl options to their long names
+var curlShortFlags = map[string]string{
+	"-X": "--request",
+	"-H": "--header",
+	"-d": "--data",
+	"-u": "--user",
+	"-F": "--form",
+	"-b": "--cookie",
+	"-A": "--user-agent",
+	"-e": "--referer",
+	"-G": "--get",
+	"-I": "--head",
+	"-k": "--insecure",
+	"-o": "--output",
+	"-m": "--max-time",
+	"-x": "--proxy",
+	"-E": "--cert",
+	"-w": "--write-out",
+	"-c": "--cookie-jar",
+	"-s": "--silent",
+	"-S": "--show-error",
+	"-L": "--location",
+	"-v": "--verbose",
+	"-i": "--include",
+	"-f": "--fail",
+	"-N": "--no-buffer",
+}
+
+// curlValueFlags are the long curl options taking a value
+var curlValueFlags = map[string]bool{
+	"--request": true, "--header": true, "--url": true,
+	"--data": true, "--data-ascii": true, "--data-raw": true, "--data-binary": true, "--data-urlencode": true, "--json": true,
+	"--form": true, "--form-string": true, "--user": true, "--cookie": true, "--user-agent": true, "--referer": true,
+	"--output": true, "--max-time": true, "--connect-timeout": true, "--proxy": true, "--cacert": true, "--cert": true,
+	"--key": true, "--write-out": true, "--cookie-jar": true, "--retry": true,
+}
+
+// curlIgnoredFlags are the curl options that do not change the request
+var curlIgnoredFlags = map[string]bool{
+	"--silent": true, "--show-error": true, "--location": true, "--verbose": true, "--include": true,
+	"--fail": true, "--no-buffer": true, "--http1.1": true, "--http2": true, "--compressed": true,
+}
+
+// Curl converts the curl command line into a request
+//
+// Paths of `@file` bodies and form files are kept as written. Options that cannot be represented
+// in a .http file are reported as warnings.
+func Curl(args []string) (*httpparser.HTTPRequest, []string, error) {
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+
+	req := &httpparser.HTTPRequest{}
+	warnings := []string{}
+	data := []string{}
+	get := false
+	head := false
+
+	options, urls, err := curlOptions(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, o := range options {
+		switch o.name {
+		case "--request":
+			req.Method = strings.ToUpper(o.value)
+		case "--header":
+			name, value, ok := strings.Cut(o.value, ":")
+			if !ok {
+				if name, ok = strings.CutSuffix(o.value, ";"); !ok {
+					warnings = append(warnings, fmt.Sprintf("header %q without a value is ignored", o.value))
+					continue
+				}
+			}
+			req.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		case "--data", "--data-ascii", "--data-binary":
+			if file, ok := strings.CutPrefix(o.value, "@"); ok {
+				req.BodyFile = file
+				continue
+			}
+			data = append(data, o.value)
+		case "--data-raw":
+			data = append(data, o.value)
+		case "--json":
+			if !req.Headers.Has("Content-Type") {
+				req.Headers.Add("Content-Type", "application/json")
+			}
+			if !req.Headers.Has("Accept") {
+				req.Headers.Add("Accept", "application/json")
+			}
+			if file, ok := strings.CutPrefix(o.value, "@"); ok {
+				req.BodyFile = file
+				continue
+			}
+			data = append(data, o.value)
+		case "--data-urlencode":
+			name, value, ok := strings.Cut(o.value, "=")
+			if !ok {
+				data = append(data, url.QueryEscape(o.value))
+				continue
+			}
+			if name == "" {
+				data = append(data, url.QueryEscape(value))
+				continue
+			}
+			data = append(data, name+"="+url.QueryEscape(value))
+		case "--form", "--form-string":
+			part, err := curlFormPart(o.value, o.name == "--form-string")
+			if err != nil {
+				return nil, nil, err
+			}
+			req.Parts = append(req.Parts, part)
+		case "--user":
+			if !strings.Contains(o.value, ":") {
+				warnings = append(warnings, "--user without a password, add it to the Authorization header")
+			}
+			req.Headers.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(o.value)))
+		case "--cookie":
+			if !strings.Contains(o.value, "=") {
+				warnings = append(warnings, fmt.Sprintf("cookie file %s is ignored", o.value))
+				continue
+			}
+			req.Headers.Add("Cookie", o.value)
+		case "--user-agent":
+			req.Headers.Add("User-Agent", o.value)
+		case "--referer":
+			req.Headers.Add("Referer", o.value)
+		case "--url":
+			urls = append(urls, o.value)
+		case "--get":
+			get = true
+		case "--head":
+			head = true
+		case "--insecure":
+			warnings = append(warnings, "--insecure: pass -k or set tls.insecure_skip_verify in restree.toml")
+		default:
+			if !curlIgnoredFlags[o.name] {
+				warnings = append(warnings, fmt.Sprintf("%s is ignored", o.name))
+			}
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, nil, fmt.Errorf("missing URL")
+	}
+	if len(urls) > 1 {
+		warnings = append(warnings, fmt.Sprintf("only the first of %d URLs is imported", len(urls)))
+	}
+	req.URL = urls[0]
+
+	body := strings.Join(data, "&")
+	switch {
+	case get && body != "":
+		separator := "?"
+		if strings.Contains(req.URL, "?") {
+			separator = "&"
+		}
+		req.URL += separator + body
+	case body != "" && req.BodyFile != "":
+		return nil, nil, fmt.Errorf("both inline data and a data file are not supported")
+	default:
+		req.Body = body
+	}
+
+	hasBody := req.Body != "" || req.BodyFile != ""
+	if hasBody && !req.Headers.Has("Content-Type") {
+		req.Headers.Add("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if len(req.Parts) > 0 {
+		// the boundary is generated when the request is built
+		req.Headers.Del("Content-Type")
+		req.Headers.Add("Content-Type", "multipart/form-data")
+	}
+
+	if req.Method == "" {
+		switch {
+		case head:
+			req.Method = "HEAD"
+		case get:
+			req.Method = "GET"
+		case hasBody || len(req.Parts) > 0:
+			req.Method = "POST"
+		default:
+			req.Method = "GET"
+		}
+	}
+
+	return req, warnings, nil
+}
+
+type curlOption struct {
+	name  string
+	value string
+}
+
+// curlOptions splits the arguments into the options with long names and the URLs
+func curlOptions(args []string) ([]curlOption, []string, error) {
+	options := []curlOption{}
+	urls := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		names := []string{}
+		value, hasValue := "", false
+		switch {
+		case arg == "--":
+			return options, append(urls, args[i+1:]...), nil
+		case strings.HasPrefix(arg, "--"):
+			name, v, ok := strings.Cut(arg, "=")
+			names = append(names, name)
+			value, hasValue = v, ok
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// short options can be combined, e.g. -sSL or -XPOST
+			for j := 1; j < len(arg); j++ {
+				name, ok := curlShortFlags["-"+string(arg[j])]
+				if !ok {
+					name = "-" + string(arg[j])
+				}
+				names = append(names, name)
+				if curlValueFlags[name] && j+1 < len(arg) {
+					value, hasValue = arg[j+1:], true
+					break
+				}
+			}
+		default:
+			urls = append(urls, arg)
+			continue
+		}
+
+		for _, name := range names {
+			if !curlValueFlags[name] {
+				options = append(options, curlOption{name: name})
+				continue
+			}
+			if !hasValue {
+				i++
+				if i == len(args) {
+					return nil, nil, fmt.Errorf("%s requires a value", name)
+				}
+				value = args[i]
+			}
+			options = append(options, curlOption{name: name, value: value})
+		}
+	}
+
+	return options, urls, nil
+}
+
+// curlFormPart converts the value of -F or --form-string, e.g. `avatar=@me.png;type=image/png`
+func curlFormPart(value string, literal bool) (httpparser.Part, error) {
+	name, content, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return httpparser.Part{}, fmt.Errorf("invalid form %q, expected name=content", value)
+	}
+	part := httpparser.Part{Name: name}
+	if literal || (!strings.HasPrefix(content, "@") && !strings.HasPrefix(content, "<")) {
+		part.Value = content
+		return part, nil
+	}
+
+	fields := splitFormFields(content[1:])
+	part.File = unquoteFormValue(fields[0])
+	for _, field := range fields[1:] {
+		key, v, _ := strings.Cut(field, "=")
+		switch strings.TrimSpace(key) {
+		case "type":
+			part.ContentType = unquoteFormValue(v)
+		case "filename":
+			part.Filename = unquoteFormValue(v)
+		}
+	}
+	return part, nil
+}
+
+// splitFormFields splits the form content on semicolons outside of double quotes
+func splitFormFields(s string) []string {
+	fields := []string{}
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuotes:
+			i++
+		case s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == ';' && !inQuotes:
+			fields = append(fields, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, s[start:])
+}
+
+func unquoteFormValue(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(s[1 : len(s)-1])
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	words, err := SplitShellWords(`curl 'http://localhost/a?b=1&c=2' \
+  -H "Authorization: Bearer \"x\"" --data-raw $'{"a":\'b\'\n}' plain\ word`)
+	assert.Eq(t, nil, err)
+
+	expected := []string{"curl", "http://localhost/a?b=1&c=2", "-H", `Authorization: Bearer "x"`, "--data-raw", "{\"a\":'b'\n}", "plain word"}
+	assert.Eq(t, len(expected), len(words))
+	for i := range expected {
+		assert.Eq(t, expected[i], words[i])
+	}
+
+	_, err = SplitShellWords(`curl 'unterminated`)
+	assert.Neq(t, nil, err)
+}
+
+func TestCurl(t *testing.T) {
+	words, err := SplitShellWords(`curl 'http://localhost/users' -XPUT -sSL --compressed \
+  -H 'Accept: application/json' -H 'content-type: application/json' -u john:secret \
+  -b 'session=abc' --data-raw '{"name":"John"}'`)
+	assert.Eq(t, nil, err)
+
+	req, warnings, err := Curl(words)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 0, len(warnings))
+	assert.Eq(t, `PUT http://localhost/users
+Accept: application/json
+content-type: application/json
+Authorization: Basic am9objpzZWNyZXQ=
+Cookie: session=abc
+
+{"name":"John"}
+`, req.Source())
+}
+
+func TestCurlData(t *testing.T) {
+	req, _, err := Curl([]string{"curl", "http://localhost/login", "-d", "user=john", "--data-urlencode", "pass=a b&c"})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "POST", req.Method)
+	assert.Eq(t, "user=john&pass=a+b%26c", req.Body)
+	assert.Eq(t, "application/x-www-form-urlencoded", headerValue(req.Headers, "Content-Type"))
+
+	req, _, err = Curl([]string{"curl", "-G", "http://localhost/search?x=1", "-d", "q=go"})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "GET http://localhost/search?x=1&q=go\n", req.Source())
+
+	req, _, err = Curl([]string{"curl", "http://localhost/import", "--data-binary", "@users.json", "-H", "Content-Type: application/json"})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "POST http://localhost/import\nContent-Type: application/json\n\n< users.json\n", req.Source())
+
+	req, _, err = Curl([]string{"curl", "http://localhost/import", "--json", "@payload.json"})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "POST http://localhost/import\nContent-Type: application/json\nAccept: application/json\n\n< payload.json\n", req.Source())
+}
+
+func TestCurlForm(t *testing.T) {
+	req, _, err := Curl([]string{"curl", "http://localhost/upload", "-F", "title=Me", "-F", `avatar=@"me;1.png";type=image/png;filename=avatar.png`, "--form-string", "note=@literal"})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, `POST http://localhost/upload
+Content-Type: multipart/form-data
+
+--- title
+Me
+--- avatar filename="avatar.png" type=image/png
+< me;1.png
+--- note
+@literal
+`, req.Source())
+}
+
+func TestCurlWarnings(t *testing.T) {
+	_, warnings, err := Curl([]string{"curl", "-k", "--max-time", "5", "http://localhost/"})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 2, len(warnings))
+
+	_, _, err = Curl([]string{"curl", "-H", "Accept: */*"})
+	assert.Neq(t, nil, err)
+}
+
+func TestStripInherited(t *testing.T) {
+	req := &httpparser.HTTPRequest{Headers: httpparser.HTTPHeaders{
+		{Name: "Accept", Value: "*/*"},
+		{Name: "Authorization", Value: "Bearer eyJ"},
+		{Name: "Content-Type", Value: "text/csv"},
+		{Name: "X-Trace", Value: "1"},
+	}}
+	StripInherited(req, httpparser.HTTPHeaders{
+		{Name: "accept", Value: "*/*"},
+		{Name: "Authorization", Value: "Bearer {{token}}"},
+		{Name: "Content-Type", Value: "application/json"},
+	})
+
+	assert.Eq(t, "GET /\nContent-Type: text/csv\nX-Trace: 1\n", (&httpparser.HTTPRequest{Method: "GET", URL: "/", Headers: req.Headers}).String())
+}
+
+func TestTemplatize(t *testing.T) {
+	req := &httpparser.HTTPRequest{
+		URL:     "http://localhost:8080/users/1",
+		Headers: httpparser.HTTPHeaders{{Name: "Authorization", Value: "Bearer abcdef"}},
+		Body:    `{"host": "http://localhost:8080", "id": 1}`,
+	}
+	Templatize(req, map[string]string{"host": "http://localhost:8080", "base": "http://localhost", "token": "abcdef", "id": "1"})
+
+	assert.Eq(t, "{{host}}/users/1", req.URL)
+	assert.Eq(t, "Bearer {{token}}", req.Headers[0].Value)
+	assert.Eq(t, `{"host": "{{host}}", "id": 1}`, req.Body)
+}
+
+func TestTemplatizeWholeValues(t *testing.T) {
+	req := &httpparser.HTTPRequest{
+		URL:     "http://localhost/products/prod?env=prod&q=production",
+		Headers: httpparser.HTTPHeaders{{Name: "X-Env", Value: "prod"}, {Name: "X-Note", Value: "production build"}, {Name: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+		Body:    "env=prod&name=products",
+	}
+	Templatize(req, map[string]string{"env": "prod"})
+
+	assert.Eq(t, "http://localhost/products/{{env}}?env={{env}}&q=production", req.URL)
+	assert.Eq(t, "{{env}}", req.Headers[0].Value)
+	assert.Eq(t, "production build", req.Headers[1].Value)
+	assert.Eq(t, "env={{env}}&name=products", req.Body)
+
+	req = &httpparser.HTTPRequest{URL: "http://localhost/", Body: `{"prod": "prod", "name": "products"}`}
+	Templatize(req, map[string]string{"env": "prod"})
+	assert.Eq(t, `{"prod": "{{env}}", "name": "products"}`, req.Body)
+}
+
+func headerValue(headers httpparser.HTTPHeaders, name string) string {
+	v, _ := headers.Get(name)
+	return v
+}
@@ -0,0 +1,474 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/restree"
+)
+
+// openAPIMethods are the operations of a path item in the order they are generated
+//
+// TRACE operations are skipped, the request files do not support the method.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// maxSchemaDepth limits the nesting of generated example bodies, e.g. for recursive schemas
+const maxSchemaDepth = 8
+
+var (
+	pathParamRegexp = regexp.MustCompile(`\{([^}]+)\}`)
+	nonWordRegexp   = regexp.MustCompile(`\W+`)
+	nonSlugRegexp   = regexp.MustCompile(`[^a-z0-9]+`)
+	camelCaseRegexp = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+type object = map[string]any
+
+// OpenAPI converts the OpenAPI 3 specification in JSON or YAML into the files of a request tree
+//
+// Every operation gets a .http file in the directory of its first tag, or of its first path segment when untagged.
+// Path, query and header parameters become {{variables}}, optional ones are only included when they have a default.
+// The server URL is written to `_vars.env` as host and the security schemes to the root `_headers.http`.
+func OpenAPI(data []byte) ([]File, []string, error) {
+	var parsed any
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		parsed, err = parseYAML(string(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid specification: %w", err)
+		}
+	}
+	spec, ok := parsed.(object)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid specification: expected an object")
+	}
+	version, _ := spec["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, nil, fmt.Errorf("unsupported specification version %q, expected OpenAPI 3", version)
+	}
+
+	c := &openAPIConverter{spec: spec, names: map[string]bool{}}
+	headers, credentials := c.headers()
+	files := []File{c.vars(credentials)}
+	if headers != nil {
+		files = append(files, *headers)
+	}
+
+	paths, _ := spec["paths"].(object)
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item, _ := c.resolve(paths[path]).(object)
+		for _, method := range openAPIMethods {
+			operation, ok := item[method].(object)
+			if !ok {
+				continue
+			}
+			files = append(files, c.operation(path, method, item, operation))
+		}
+		if _, ok := item["trace"]; ok {
+			c.warn("TRACE %s: the method is not supported, skipped", path)
+		}
+	}
+
+	return files, c.warnings, nil
+}
+
+type openAPIConverter struct {
+	spec     object
+	warnings []string
+	// names are the generated file paths, to keep them unique
+	names map[string]bool
+}
+
+func (c *openAPIConverter) warn(format string, args ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// resolve follows the local $ref of the value
+func (c *openAPIConverter) resolve(value any) any {
+	for range maxSchemaDepth {
+		obj, ok := value.(object)
+		if !ok {
+			return value
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return value
+		}
+		pointer, ok := strings.CutPrefix(ref, "#/")
+		if !ok {
+			c.warn("external reference %s is not supported", ref)
+			return nil
+		}
+
+		var current any = c.spec
+		for _, token := range strings.Split(pointer, "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			parent, _ := current.(object)
+			current = parent[token]
+		}
+		value = current
+	}
+	return value
+}
+
+// vars generates the `_vars.env` file with the URL of the first server and the credentials of the security schemes
+//
+// The credentials are commented out, so they do not override the values of `.env` in the same directory.
+func (c *openAPIConverter) vars(credentials []string) File {
+	host := "http://localhost"
+	unresolved := []string{}
+	servers, _ := c.spec["servers"].([]any)
+	if len(servers) == 0 {
+		c.warn("no servers, host defaults to %s", host)
+	} else {
+		server, _ := servers[0].(object)
+		serverURL, _ := server["url"].(string)
+		variables, _ := server["variables"].(object)
+		serverURL = pathParamRegexp.ReplaceAllStringFunc(serverURL, func(m string) string {
+			name := m[1 : len(m)-1]
+			variable, _ := variables[name].(object)
+			if def, ok := variable["default"]; ok {
+				return fmt.Sprint(def)
+			}
+			if enum := asSlice(variable["enum"]); len(enum) > 0 {
+				c.warn("server variable %s has no default, using %v", name, enum[0])
+				return fmt.Sprint(enum[0])
+			}
+			unresolved = append(unresolved, name)
+			return m
+		})
+		if strings.HasPrefix(serverURL, "/") {
+			c.warn("relative server URL %s is resolved against %s", serverURL, host)
+			serverURL = host + serverURL
+		}
+		host = strings.TrimSuffix(serverURL, "/")
+	}
+
+	content := fmt.Sprintf("host=%s\n", host)
+	if len(unresolved) > 0 {
+		// env files are not expanded, so the host is left for the user to complete
+		c.warn("server variables %s have no default, set host in .env", strings.Join(unresolved, ", "))
+		content = fmt.Sprintf("# server variables without a default: %s\n# host=%s\n", strings.Join(unresolved, ", "), host)
+	}
+	if len(credentials) > 0 {
+		content += "\n# credentials of the security schemes, set them here or in .env\n"
+		for _, name := range credentials {
+			content += fmt.Sprintf("# %s=\n", name)
+		}
+	}
+	return File{Path: restree.VarsFileName, Content: content}
+}
+
+// headers generates the root `_headers.http` file with the headers of the security schemes and returns their variables
+//
+// The variables default to empty, so requests build before the credentials are set.
+func (c *openAPIConverter) headers() (*File, []string) {
+	components, _ := c.spec["components"].(object)
+	schemes, _ := components["securitySchemes"].(object)
+
+	names := slices.Sorted(maps.Keys(schemes))
+	if security, ok := c.spec["security"].([]any); ok {
+		names = []string{}
+		if len(security) > 0 {
+			requirement, _ := security[0].(object)
+			names = slices.Sorted(maps.Keys(requirement))
+		}
+	}
+
+	headers := httpparser.HTTPHeaders{}
+	credentials := []string{}
+	for _, name := range names {
+		scheme, _ := c.resolve(schemes[name]).(object)
+		schemeType, _ := scheme["type"].(string)
+		httpScheme, _ := scheme["scheme"].(string)
+
+		switch {
+		case schemeType == "http" && strings.EqualFold(httpScheme, "basic"):
+			headers.Set("Authorization", "Basic {{basic_auth:-}}")
+			credentials = append(credentials, "basic_auth")
+		case schemeType == "http" || schemeType == "oauth2" || schemeType == "openIdConnect":
+			headers.Set("Authorization", "Bearer {{token:-}}")
+			credentials = append(credentials, "token")
+		case schemeType == "apiKey" && scheme["in"] == "header":
+			header, _ := scheme["name"].(string)
+			variable := variableName(name)
+			headers.Set(header, "{{"+variable+":-}}")
+			credentials = append(credentials, variable)
+		default:
+			c.warn("security scheme %s of type %s in %v is not supported", name, schemeType, scheme["in"])
+		}
+	}
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("# security schemes, the credentials are listed in " + restree.VarsFileName + "\n")
+	for _, h := range headers {
+		b.WriteString(h.String() + "\n")
+	}
+	return &File{Path: restree.HeadersFileName, Content: b.String()}, slices.Compact(credentials)
+}
+
+// operation generates the .http file of the operation
+func (c *openAPIConverter) operation(path string, method string, item object, operation object) File {
+	req := &httpparser.HTTPRequest{
+		Method:  strings.ToUpper(method),
+		Headers: httpparser.HTTPHeaders{},
+	}
+
+	params := append(slices.Clone(asSlice(item["parameters"])), asSlice(operation["parameters"])...)
+	query := []string{}
+	pathVariables := map[string]string{}
+	for _, p := range params {
+		param, _ := c.resolve(p).(object)
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		required, _ := param["required"].(bool)
+		schema, _ := c.resolve(param["schema"]).(object)
+
+		placeholder := "{{" + variableName(name) + "}}"
+		if !required && in != "path" {
+			def, ok := schema["default"]
+			if !ok {
+				continue
+			}
+			placeholder = "{{" + variableName(name) + ":-" + fmt.Sprint(def) + "}}"
+		}
+
+		switch in {
+		case "path":
+			pathVariables[name] = placeholder
+		case "query":
+			query = append(query, url.QueryEscape(name)+"="+placeholder)
+		case "header":
+			req.Headers.Set(name, placeholder)
+		default:
+			c.warn("%s %s: %s parameter %s is not supported", req.Method, path, in, name)
+		}
+	}
+
+	req.URL = "{{host}}" + pathParamRegexp.ReplaceAllStringFunc(path, func(m string) string {
+		name := m[1 : len(m)-1]
+		if placeholder, ok := pathVariables[name]; ok {
+			return placeholder
+		}
+		return "{{" + variableName(name) + "}}"
+	})
+	if len(query) > 0 {
+		req.URL += "?" + strings.Join(query, "&")
+	}
+
+	c.requestBody(req, path, operation)
+
+	name, _ := operation["operationId"].(string)
+	comment, _ := operation["summary"].(string)
+	if comment == "" {
+		comment, _ = operation["description"].(string)
+	}
+
+	return File{Path: c.operationPath(path, method, operation), Content: requestSource(name, comment, req)}
+}
+
+// requestBody sets the example body of the preferred content type of the operation
+func (c *openAPIConverter) requestBody(req *httpparser.HTTPRequest, path string, operation object) {
+	body, _ := c.resolve(operation["requestBody"]).(object)
+	content, _ := body["content"].(object)
+	if len(content) == 0 {
+		return
+	}
+
+	types := slices.Sorted(maps.Keys(content))
+	contentType := types[0]
+	for _, preferred := range []string{"application/json", "+json", "application/x-www-form-urlencoded", "multipart/form-data"} {
+		if i := slices.IndexFunc(types, func(t string) bool { return strings.HasSuffix(t, preferred) }); i >= 0 {
+			contentType = types[i]
+			break
+		}
+	}
+	media, _ := content[contentType].(object)
+	example := c.mediaExample(media)
+
+	switch {
+	case contentType == "multipart/form-data":
+		req.Headers.Set("Content-Type", contentType)
+		schema, _ := c.resolve(media["schema"]).(object)
+		properties, _ := schema["properties"].(object)
+		values, _ := example.(object)
+		for _, name := range slices.Sorted(maps.Keys(properties)) {
+			property, _ := c.resolve(properties[name]).(object)
+			if property["format"] == "binary" || property["format"] == "base64" {
+				req.Parts = append(req.Parts, httpparser.Part{Name: name, File: "./" + name})
+				c.warn("%s %s: file part %s references ./%s", req.Method, path, name, name)
+				continue
+			}
+			req.Parts = append(req.Parts, httpparser.Part{Name: name, Value: scalarString(values[name])})
+		}
+	case contentType == "application/x-www-form-urlencoded":
+		req.Headers.Set("Content-Type", contentType)
+		values, _ := example.(object)
+		form := []string{}
+		for _, name := range slices.Sorted(maps.Keys(values)) {
+			form = append(form, url.QueryEscape(name)+"="+url.QueryEscape(scalarString(values[name])))
+		}
+		req.Body = strings.Join(form, "&")
+	case strings.HasSuffix(contentType, "json"):
+		req.Headers.Set("Content-Type", contentType)
+		if example == nil {
+			return
+		}
+		b, err := json.MarshalIndent(example, "", "  ")
+		if err != nil {
+			c.warn("%s %s: unable to encode the example body: %s", req.Method, path, err)
+			return
+		}
+		req.Body = string(b)
+	default:
+		req.Headers.Set("Content-Type", contentType)
+		if s, ok := example.(string); ok {
+			req.Body = s
+		}
+	}
+}
+
+// mediaExample returns the example of the media type, generated from its schema when it has none
+func (c *openAPIConverter) mediaExample(media object) any {
+	if example, ok := media["example"]; ok {
+		return example
+	}
+	if examples, ok := media["examples"].(object); ok && len(examples) > 0 {
+		example, _ := c.resolve(examples[slices.Sorted(maps.Keys(examples))[0]]).(object)
+		if value, ok := example["value"]; ok {
+			return value
+		}
+	}
+	return c.schemaExample(media["schema"], 0)
+}
+
+// schemaExample generates an example value of the schema
+func (c *openAPIConverter) schemaExample(value any, depth int) any {
+	schema, _ := c.resolve(value).(object)
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+
+	for _, key := range []string{"example", "default"} {
+		if example, ok := schema[key]; ok {
+			return example
+		}
+	}
+	if enum := asSlice(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if all := asSlice(schema["allOf"]); len(all) > 0 {
+		merged := object{}
+		for _, s := range all {
+			if example, ok := c.schemaExample(s, depth+1).(object); ok {
+				maps.Copy(merged, example)
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alternatives := asSlice(schema[key]); len(alternatives) > 0 {
+			return c.schemaExample(alternatives[0], depth+1)
+		}
+	}
+
+	schemaType, _ := schema["type"].(string)
+	if types := asSlice(schema["type"]); len(types) > 0 {
+		// OpenAPI 3.1 allows a list of types, e.g. [string, "null"]
+		schemaType, _ = types[0].(string)
+	}
+	switch {
+	case schemaType == "object" || schema["properties"] != nil:
+		example := object{}
+		properties, _ := schema["properties"].(object)
+		for name, property := range properties {
+			example[name] = c.schemaExample(property, depth+1)
+		}
+		return example
+	case schemaType == "array":
+		return []any{c.schemaExample(schema["items"], depth+1)}
+	case schemaType == "integer" || schemaType == "number":
+		return 0
+	case schemaType == "boolean":
+		return false
+	case schemaType == "string":
+		switch schema["format"] {
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "date":
+			return "2006-01-02"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// operationPath returns the unique path of the .http file of the operation
+func (c *openAPIConverter) operationPath(urlPath string, method string, operation object) string {
+	segments := []string{}
+	for _, segment := range strings.Split(urlPath, "/") {
+		if segment != "" && !pathParamRegexp.MatchString(segment) {
+			segments = append(segments, segment)
+		}
+	}
+
+	dir := ""
+	if tags := asSlice(operation["tags"]); len(tags) > 0 {
+		dir = slug(fmt.Sprint(tags[0]))
+	} else if len(segments) > 0 {
+		dir = slug(segments[0])
+	}
+
+	name, _ := operation["operationId"].(string)
+	if name == "" {
+		name = method + "-" + strings.Join(strings.FieldsFunc(urlPath, func(r rune) bool { return r == '/' || r == '{' || r == '}' }), "-")
+	}
+	name = slug(name)
+
+	return uniquePath(c.names, path.Join(dir, name), ".http")
+}
+
+func asSlice(value any) []any {
+	s, _ := value.([]any)
+	return s
+}
+
+func scalarString(value any) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// variableName converts the name to a valid variable name, e.g. X-Request-ID to X_Request_ID
+func variableName(name string) string {
+	return strings.Trim(nonWordRegexp.ReplaceAllString(name, "_"), "_")
+}
+
+// slug converts the name to a file name, e.g. "List Users" or listUsers to list-users
+func slug(name string) string {
+	name = strings.ToLower(camelCaseRegexp.ReplaceAllString(name, "$1-$2"))
+	return strings.Trim(nonSlugRegexp.ReplaceAllString(name, "-"), "-")
+}
@@ -0,0 +1,204 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/restree"
+)
+
+const validOpenAPISpec = `openapi: 3.0.3
+servers:
+  - url: https://{env}.example.com/v1
+    variables:
+      env:
+        default: api
+security:
+  - apiKey: []
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      summary: List users
+      tags: [Users]
+      parameters:
+        - name: page
+          in: query
+          schema:
+            type: integer
+            default: 1
+        - name: filter
+          in: query
+          schema:
+            type: string
+    post:
+      operationId: createUser
+      tags: [Users]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+  /users/{user-id}:
+    delete:
+      parameters:
+        - name: user-id
+          in: path
+          required: true
+          schema:
+            type: string
+components:
+  securitySchemes:
+    apiKey:
+      type: apiKey
+      in: header
+      name: X-API-Key
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+          example: John
+        email:
+          type: string
+          format: email
+`
+
+func validOpenAPIFiles(t *testing.T) map[string]string {
+	files, warnings, err := OpenAPI([]byte(validOpenAPISpec))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 0, len(warnings))
+
+	byPath := map[string]string{}
+	for _, f := range files {
+		byPath[f.Path] = f.Content
+	}
+	return byPath
+}
+
+func TestOpenAPI(t *testing.T) {
+	files := validOpenAPIFiles(t)
+	assert.Eq(t, 5, len(files))
+
+	assert.Eq(t, "host=https://api.example.com/v1\n\n# credentials of the security schemes, set them here or in .env\n# apiKey=\n", files["_vars.env"])
+	assert.Eq(t, "# security schemes, the credentials are listed in _vars.env\nX-API-Key: {{apiKey:-}}\n", files["_headers.http"])
+	assert.Eq(t, `### listUsers
+# List users
+GET {{host}}/users?page={{page:-1}}
+`, files["users/list-users.http"])
+	assert.Eq(t, `### createUser
+POST {{host}}/users
+Content-Type: application/json
+
+{
+  "email": "user@example.com",
+  "name": "John"
+}
+`, files["users/create-user.http"])
+	assert.Eq(t, "DELETE {{host}}/users/{{user_id}}\n", files["users/delete-users-user-id.http"])
+}
+
+func TestOpenAPIJSON(t *testing.T) {
+	files, _, err := OpenAPI([]byte(`{"openapi": "3.1.0", "paths": {"/a": {"get": {}, "put": {"operationId": "a"}}, "/a/{id}": {"get": {"operationId": "a"}}}}`))
+	assert.Eq(t, nil, err)
+
+	paths := []string{}
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	assert.Eq(t, "_vars.env a/get-a.http a/a.http a/a-2.http", joinWords(paths))
+}
+
+func TestOpenAPIServerVariables(t *testing.T) {
+	files, warnings, err := OpenAPI([]byte(`{"openapi": "3.0.0", "servers": [{"url": "https://{region}.example.com/{version}",
+  "variables": {"region": {"enum": ["eu", "us"]}, "version": {"default": "v1"}}}], "paths": {}}`))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 1, len(warnings))
+	assert.Eq(t, "host=https://eu.example.com/v1\n", files[0].Content)
+
+	files, warnings, err = OpenAPI([]byte(`{"openapi": "3.0.0", "servers": [{"url": "https://{region}.example.com",
+  "variables": {"region": {}}}], "paths": {}}`))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 1, len(warnings))
+	assert.Eq(t, "# server variables without a default: region\n# host=https://{region}.example.com\n", files[0].Content)
+}
+
+func TestOpenAPIParsable(t *testing.T) {
+	spec := strings.Replace(validOpenAPISpec, "  /users/{user-id}:\n", "  /users/{user-id}:\n    trace:\n      operationId: traceUser\n", 1)
+	files, warnings, err := OpenAPI([]byte(spec))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 1, len(warnings))
+	assert.Eq(t, "TRACE /users/{user-id}: the method is not supported, skipped", warnings[0])
+
+	for _, f := range files {
+		switch {
+		case f.Path == restree.HeadersFileName:
+			_, err = httpparser.ParseHeadersFile(strings.NewReader(f.Content))
+		case strings.HasSuffix(f.Path, ".http"):
+			_, err = httpparser.ParseAll(strings.NewReader(f.Content))
+		default:
+			continue
+		}
+		assert.Eq(t, nil, err)
+	}
+	assert.Eq(t, 5, len(files))
+}
+
+func TestOpenAPIBuildsWithoutCredentials(t *testing.T) {
+	files, _, err := OpenAPI([]byte(`{"openapi": "3.0.0", "paths": {"/a": {"get": {"operationId": "a"}}},
+  "components": {"securitySchemes": {"basic": {"type": "http", "scheme": "basic"}, "key": {"type": "apiKey", "in": "header", "name": "X-Key"}}}}`))
+	assert.Eq(t, nil, err)
+
+	dir := t.TempDir()
+	_, err = WriteFiles(dir, files)
+	assert.Eq(t, nil, err)
+
+	req, err := restree.RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "a", "a.http"), restree.Variables{}, restree.RecursiveReadOpts{})
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "Basic ", req.Headers.Values("Authorization")[0])
+	assert.Eq(t, "", req.Headers.Values("X-Key")[0])
+}
+
+func TestOpenAPIInvalid(t *testing.T) {
+	_, _, err := OpenAPI([]byte(`swagger: "2.0"`))
+	assert.Neq(t, nil, err)
+
+	_, _, err = OpenAPI([]byte(`- a`))
+	assert.Neq(t, nil, err)
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []File{{Path: "a.http", Content: "GET /a\n"}, {Path: "b/b.http", Content: "GET /b\n"}}
+
+	report, err := WriteFiles(dir, files)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 2, len(report.Created))
+
+	err = os.WriteFile(filepath.Join(dir, "b", "b.http"), []byte("GET /edited\n"), 0o644)
+	assert.Eq(t, nil, err)
+
+	files[0].Content = "GET /a2\n"
+	files[1].Content = "GET /b2\n"
+	report, err = WriteFiles(dir, files)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "a.http", joinWords(report.Updated))
+	assert.Eq(t, "b/b.http", joinWords(report.Kept))
+
+	b, err := os.ReadFile(filepath.Join(dir, "b", "b.http"))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "GET /edited\n", string(b))
+
+	report, err = WriteFiles(dir, files)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "a.http", joinWords(report.Unchanged))
+}
+
+func joinWords(words []string) string {
+	return strings.Join(words, " ")
+}
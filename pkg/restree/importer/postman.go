@@ -0,0 +1,587 @@
+package importer
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/restree"
+)
+
+// postmanDynamicVariables maps the dynamic variables of Postman to the ones of restree
+var postmanDynamicVariables = map[string]string{
+	"$guid":         "$uuid",
+	"$randomUUID":   "$uuid",
+	"$timestamp":    "$timestamp",
+	"$isoTimestamp": "$isoTimestamp",
+	"$randomInt":    "$randomInt",
+}
+
+// postmanRawLanguages maps the languages of raw bodies to their content type
+var postmanRawLanguages = map[string]string{
+	"json":       "application/json",
+	"xml":        "application/xml",
+	"html":       "text/html",
+	"javascript": "application/javascript",
+	"text":       "text/plain",
+}
+
+var (
+	postmanPathVariableRegexp = regexp.MustCompile(`/:(\w+)`)
+	postmanPlaceholderRegexp  = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+)
+
+type postmanCollection struct {
+	Variable []postmanVariable `json:"variable"`
+	postmanItem
+}
+
+type postmanItem struct {
+	Name        string             `json:"name"`
+	Description postmanDescription `json:"description"`
+	Item        []postmanItem      `json:"item"`
+	Request     *postmanRequest    `json:"request"`
+	Auth        *postmanAuth       `json:"auth"`
+	Event       []postmanEvent     `json:"event"`
+}
+
+type postmanRequest struct {
+	Method      string             `json:"method"`
+	URL         postmanURL         `json:"url"`
+	Header      []postmanKeyValue  `json:"header"`
+	Body        *postmanBody       `json:"body"`
+	Auth        *postmanAuth       `json:"auth"`
+	Description postmanDescription `json:"description"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+// UnmarshalJSON accepts URLs given as a string or as an object
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &u.Raw); err == nil {
+		return nil
+	}
+	type plain postmanURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+// postmanDescription is a description given as a string or as an object with the content
+type postmanDescription string
+
+func (d *postmanDescription) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = postmanDescription(s)
+		return nil
+	}
+	var obj struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*d = postmanDescription(obj.Content)
+	return nil
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+	// Type is text or file for form data
+	Type        string `json:"type"`
+	Src         any    `json:"src"`
+	ContentType string `json:"contentType"`
+}
+
+func (kv postmanKeyValue) value() string {
+	return scalarString(kv.Value)
+}
+
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+	// Disabled is set for collection variables and Enabled for environment values
+	Disabled bool  `json:"disabled"`
+	Enabled  *bool `json:"enabled"`
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	URLEncoded []postmanKeyValue `json:"urlencoded"`
+	FormData   []postmanKeyValue `json:"formdata"`
+	File       struct {
+		Src string `json:"src"`
+	} `json:"file"`
+	GraphQL struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanKeyValue `json:"bearer"`
+	Basic  []postmanKeyValue `json:"basic"`
+	APIKey []postmanKeyValue `json:"apikey"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec postmanExec `json:"exec"`
+	} `json:"script"`
+}
+
+// postmanExec are the lines of a script given as a list or as one string
+type postmanExec []string
+
+func (e *postmanExec) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*e = strings.Split(s, "\n")
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(e))
+}
+
+// Postman converts the Postman v2 collection into the files of a request tree
+//
+// Folders become directories and requests .http files. The authorization of the collection and
+// the folders, as well as headers shared by all requests of a folder, go to `_headers.http`.
+// Collection variables are written to `_vars.env` and pre-request scripts to commented `_before.sh` stubs.
+// Everything that could not be converted is reported as warnings.
+func Postman(data []byte) ([]File, []string, error) {
+	var collection postmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, nil, fmt.Errorf("invalid collection: %w", err)
+	}
+	if collection.Item == nil {
+		return nil, nil, fmt.Errorf("invalid collection: missing items")
+	}
+
+	c := &postmanConverter{names: map[string]bool{}}
+	if vars := c.variables(restree.VarsFileName, collection.Variable, "collection"); vars != nil {
+		c.files = append(c.files, *vars)
+	}
+	c.folder("", &collection.postmanItem, httpparser.HTTPHeaders{}, "")
+
+	return c.files, c.warnings, nil
+}
+
+// PostmanEnvironment converts the Postman environment into the `_env/<name>.env` profile
+func PostmanEnvironment(data []byte) (File, []string, error) {
+	var environment struct {
+		Name   string            `json:"name"`
+		Values []postmanVariable `json:"values"`
+	}
+	if err := json.Unmarshal(data, &environment); err != nil {
+		return File{}, nil, fmt.Errorf("invalid environment: %w", err)
+	}
+	name := slug(environment.Name)
+	if name == "" {
+		return File{}, nil, fmt.Errorf("invalid environment: missing name")
+	}
+
+	c := &postmanConverter{}
+	profile := c.variables(path.Join(restree.EnvDirName, name+".env"), environment.Values, "environment "+environment.Name)
+	if profile == nil {
+		profile = &File{Path: path.Join(restree.EnvDirName, name+".env")}
+	}
+	return *profile, c.warnings, nil
+}
+
+type postmanConverter struct {
+	files    []File
+	warnings []string
+	// names are the generated file paths, to keep them unique
+	names map[string]bool
+}
+
+func (c *postmanConverter) warn(format string, args ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// variables generates the env file with the enabled variables, nil when there are none
+//
+// Env files are not expanded, so references to other variables of the file are resolved
+// and the references left are reported.
+func (c *postmanConverter) variables(filePath string, variables []postmanVariable, source string) *File {
+	keys := []string{}
+	values := map[string]string{}
+	for _, v := range variables {
+		if v.Disabled || (v.Enabled != nil && !*v.Enabled) {
+			continue
+		}
+		key := v.Key
+		if name := variableName(key); name != key {
+			c.warn("%s: variable %q is renamed to %s", source, key, name)
+			key = name
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = c.placeholders(scalarString(v.Value), source)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	for _, key := range keys {
+		value := resolveReferences(values[key], values)
+		if refs := postmanPlaceholderRegexp.FindAllString(value, -1); len(refs) > 0 {
+			c.warn("%s: variable %s references %s, env files are not expanded, set its value in .env", source, key, strings.Join(refs, ", "))
+		} else if value != values[key] {
+			c.warn("%s: variable %s is resolved to %s, it does not follow the variables it references", source, key, value)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, envValue(value))
+	}
+	return &File{Path: filePath, Content: b.String()}
+}
+
+// resolveReferences replaces the {{name}} references of the value with the values of the variables
+func resolveReferences(value string, variables map[string]string) string {
+	for range len(variables) {
+		resolved := postmanPlaceholderRegexp.ReplaceAllStringFunc(value, func(m string) string {
+			if v, ok := variables[m[2:len(m)-2]]; ok {
+				return v
+			}
+			return m
+		})
+		if resolved == value {
+			break
+		}
+		value = resolved
+	}
+	return value
+}
+
+// folder converts the items of the folder in dir, inherited are the headers of the parent directories
+//
+// The source is the path of the folder in the collection used in warnings, empty for the collection itself.
+func (c *postmanConverter) folder(dir string, folder *postmanItem, inherited httpparser.HTTPHeaders, source string) {
+	label := cmp.Or(source, "collection")
+	headers := httpparser.HTTPHeaders{}
+	if folder.Auth != nil {
+		c.auth(&headers, folder.Auth, label)
+	}
+	for _, h := range c.sharedHeaders(folder) {
+		if !slices.Contains(inherited, h) && !headers.Has(h.Name) {
+			headers.Add(h.Name, h.Value)
+		}
+	}
+	if len(headers) > 0 {
+		var b strings.Builder
+		for _, h := range headers {
+			b.WriteString(h.String() + "\n")
+		}
+		c.files = append(c.files, File{Path: path.Join(dir, restree.HeadersFileName), Content: b.String()})
+	}
+	inherited = inherited.Merge(headers)
+
+	scripts := c.scripts(folder.Event, label)
+	dirs := map[string]bool{}
+	for i := range folder.Item {
+		item := &folder.Item[i]
+		name := item.Name
+		if name == "" {
+			name = fmt.Sprintf("item %d", i+1)
+		}
+		itemSource := name
+		if source != "" {
+			itemSource = source + " / " + name
+		}
+
+		if item.Request == nil {
+			sub := uniquePath(dirs, path.Join(dir, cmp.Or(slug(name), "folder")), "")
+			c.folder(sub, item, inherited, itemSource)
+			continue
+		}
+
+		c.request(dir, item, inherited, itemSource)
+		if lines := c.scripts(item.Event, itemSource); len(lines) > 0 {
+			c.warn("%s: the pre-request script runs for all requests of the directory, it is added to %s", itemSource, path.Join(dir, restree.BeforeScriptFileName))
+			scripts = append(scripts, lines...)
+		}
+	}
+
+	if len(scripts) > 0 {
+		var b strings.Builder
+		b.WriteString("#!/bin/sh\n")
+		b.WriteString("# Pre-request scripts imported from Postman, port them to shell.\n")
+		b.WriteString("# Variables are printed as name=value lines, e.g. echo \"token=$(./login.sh)\".\n")
+		for _, line := range scripts {
+			b.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+		}
+		c.files = append(c.files, File{Path: path.Join(dir, restree.BeforeScriptFileName), Content: b.String()})
+	}
+}
+
+// scripts returns the commented lines of the pre-request scripts, test scripts are reported
+func (c *postmanConverter) scripts(events []postmanEvent, source string) []string {
+	lines := []string{}
+	for _, e := range events {
+		exec := slices.DeleteFunc(slices.Clone(e.Script.Exec), func(line string) bool { return strings.TrimSpace(line) == "" })
+		if len(exec) == 0 {
+			continue
+		}
+		switch e.Listen {
+		case "prerequest":
+			lines = append(lines, "", source+":")
+			lines = append(lines, exec...)
+		case "test":
+			c.warn("%s: test script is not converted, use @capture and @assert directives or an %s script", source, restree.AfterScriptFileName)
+		default:
+			c.warn("%s: %s script is not converted", source, e.Listen)
+		}
+	}
+	return lines
+}
+
+// sharedHeaders returns the headers set to the same value by all requests of the folder with at least two requests
+func (c *postmanConverter) sharedHeaders(folder *postmanItem) httpparser.HTTPHeaders {
+	requests := []*postmanRequest{}
+	var collect func(item *postmanItem)
+	collect = func(item *postmanItem) {
+		if item.Request != nil {
+			requests = append(requests, item.Request)
+		}
+		for i := range item.Item {
+			collect(&item.Item[i])
+		}
+	}
+	collect(folder)
+	if len(requests) < 2 {
+		return nil
+	}
+
+	shared := httpparser.HTTPHeaders{}
+	for _, h := range enabledHeaders(requests[0].Header) {
+		inAll := true
+		for _, r := range requests[1:] {
+			if !slices.Contains(enabledHeaders(r.Header), h) {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			// unsupported variables are reported with the requests
+			shared = append(shared, httpparser.HTTPHeader{Name: h.Name, Value: (&postmanConverter{}).placeholders(h.Value, "")})
+		}
+	}
+	return shared
+}
+
+func enabledHeaders(headers []postmanKeyValue) httpparser.HTTPHeaders {
+	enabled := httpparser.HTTPHeaders{}
+	for _, h := range headers {
+		if !h.Disabled {
+			enabled = append(enabled, httpparser.HTTPHeader{Name: h.Key, Value: h.value()})
+		}
+	}
+	return enabled
+}
+
+// request converts the request of the item into a .http file in dir
+func (c *postmanConverter) request(dir string, item *postmanItem, inherited httpparser.HTTPHeaders, source string) {
+	r := item.Request
+	req := &httpparser.HTTPRequest{
+		Method:  strings.ToUpper(cmp.Or(r.Method, "GET")),
+		Headers: httpparser.HTTPHeaders{},
+	}
+	if !httpparser.IsHTTPMethod(req.Method) {
+		c.warn("%s: method %s is not supported, the request is skipped", source, req.Method)
+		return
+	}
+
+	pathVariables := map[string]string{}
+	for _, v := range r.URL.Variable {
+		pathVariables[v.Key] = v.value()
+	}
+	req.URL = postmanPathVariableRegexp.ReplaceAllStringFunc(c.placeholders(r.URL.Raw, source), func(m string) string {
+		name := m[2:]
+		if value := pathVariables[name]; value != "" && !strings.Contains(value, "{{") {
+			return "/{{" + name + ":-" + value + "}}"
+		}
+		return "/{{" + name + "}}"
+	})
+	if req.URL == "" {
+		c.warn("%s: missing URL", source)
+	}
+
+	for _, h := range enabledHeaders(r.Header) {
+		req.Headers.Add(h.Name, c.placeholders(h.Value, source))
+	}
+	if r.Auth != nil {
+		c.auth(&req.Headers, r.Auth, source)
+		if r.Auth.Type == "noauth" && inherited.Has("Authorization") && !req.Headers.Has("Authorization") {
+			req.Headers = append(req.Headers, httpparser.HTTPHeader{Name: "Authorization", Op: httpparser.HeaderUnset})
+		}
+	}
+	req.Headers = slices.DeleteFunc(req.Headers, func(h httpparser.HTTPHeader) bool { return slices.Contains(inherited, h) })
+	if r.Body != nil {
+		c.body(req, r.Body, source)
+	}
+
+	file := uniquePath(c.names, path.Join(dir, cmp.Or(slug(item.Name), "request")), ".http")
+	c.files = append(c.files, File{Path: file, Content: requestSource(item.Name, string(cmp.Or(r.Description, item.Description)), req)})
+}
+
+// body sets the body of the request
+func (c *postmanConverter) body(req *httpparser.HTTPRequest, body *postmanBody, source string) {
+	setContentType := func(contentType string) {
+		if contentType != "" && !req.Headers.Has("Content-Type") {
+			req.Headers.Add("Content-Type", contentType)
+		}
+	}
+
+	switch body.Mode {
+	case "raw":
+		req.Body = c.placeholders(body.Raw, source)
+		setContentType(postmanRawLanguages[body.Options.Raw.Language])
+	case "urlencoded":
+		form := []string{}
+		for _, kv := range body.URLEncoded {
+			if !kv.Disabled {
+				form = append(form, url.QueryEscape(kv.Key)+"="+c.placeholders(kv.value(), source))
+			}
+		}
+		req.Body = strings.Join(form, "&")
+		setContentType("application/x-www-form-urlencoded")
+	case "formdata":
+		for _, kv := range body.FormData {
+			if kv.Disabled {
+				continue
+			}
+			part := httpparser.Part{Name: kv.Key, ContentType: kv.ContentType}
+			if kv.Type != "file" {
+				part.Value = c.placeholders(kv.value(), source)
+				req.Parts = append(req.Parts, part)
+				continue
+			}
+
+			src := kv.Src
+			if files, ok := src.([]any); ok && len(files) > 0 {
+				if len(files) > 1 {
+					c.warn("%s: only the first of %d files of part %s is imported", source, len(files), kv.Key)
+				}
+				src = files[0]
+			}
+			file, _ := src.(string)
+			if file == "" {
+				c.warn("%s: file part %s without a file is ignored", source, kv.Key)
+				continue
+			}
+			c.warn("%s: check the path %s of file part %s", source, file, kv.Key)
+			part.File = file
+			req.Parts = append(req.Parts, part)
+		}
+		req.Headers.Del("Content-Type")
+		req.Headers.Add("Content-Type", "multipart/form-data")
+	case "file":
+		if body.File.Src == "" {
+			c.warn("%s: body file without a path is ignored", source)
+			return
+		}
+		c.warn("%s: check the path %s of the body file", source, body.File.Src)
+		req.BodyFile = body.File.Src
+	case "graphql":
+		query := map[string]any{"query": body.GraphQL.Query}
+		if strings.TrimSpace(body.GraphQL.Variables) != "" {
+			var variables any
+			if err := json.Unmarshal([]byte(body.GraphQL.Variables), &variables); err != nil {
+				c.warn("%s: invalid GraphQL variables are ignored: %s", source, err)
+			} else {
+				query["variables"] = variables
+			}
+		}
+		b, err := json.MarshalIndent(query, "", "  ")
+		if err != nil {
+			c.warn("%s: unable to encode the GraphQL query: %s", source, err)
+			return
+		}
+		req.Body = c.placeholders(string(b), source)
+		setContentType("application/json")
+	case "", "none":
+	default:
+		c.warn("%s: body mode %s is not supported", source, body.Mode)
+	}
+}
+
+// auth sets the Authorization header, or the header of the API key
+func (c *postmanConverter) auth(headers *httpparser.HTTPHeaders, auth *postmanAuth, source string) {
+	get := func(values []postmanKeyValue, key string) string {
+		for _, kv := range values {
+			if kv.Key == key {
+				return c.placeholders(kv.value(), source)
+			}
+		}
+		return ""
+	}
+
+	switch auth.Type {
+	case "bearer":
+		headers.Set("Authorization", "Bearer "+get(auth.Bearer, "token"))
+	case "basic":
+		username, password := get(auth.Basic, "username"), get(auth.Basic, "password")
+		credentials := username + ":" + password
+		if strings.Contains(credentials, "{{") {
+			// variables are expanded before the functions of the pipeline are applied
+			headers.Set("Authorization", "Basic {{basic_auth}}")
+			c.warn("%s: basic auth with variables, set basic_auth to the base64 of %s", source, credentials)
+			return
+		}
+		headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	case "apikey":
+		key, value := get(auth.APIKey, "key"), get(auth.APIKey, "value")
+		if in := get(auth.APIKey, "in"); in == "query" {
+			c.warn("%s: API key in the query is not converted, add %s={{...}} to the URLs", source, key)
+			return
+		}
+		headers.Set(key, value)
+	case "noauth", "inherit", "":
+	default:
+		c.warn("%s: %s auth is not supported", source, auth.Type)
+	}
+}
+
+// placeholders converts the Postman variables of s, dynamic variables not known to restree are reported
+func (c *postmanConverter) placeholders(s string, source string) string {
+	return postmanPlaceholderRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		name := strings.TrimSpace(m[2 : len(m)-2])
+		if strings.HasPrefix(name, "$") {
+			dynamic, ok := postmanDynamicVariables[name]
+			if !ok {
+				c.warn("%s: dynamic variable %s is not supported", source, name)
+				return m
+			}
+			return "{{" + dynamic + "}}"
+		}
+		return "{{" + variableName(name) + "}}"
+	})
+}
+
+// envValue quotes the value of an env file when needed
+func envValue(value string) string {
+	if value == "" || (!strings.ContainsAny(value, "\"'\n\r\t\\") && !strings.Contains(value, " #") && strings.TrimSpace(value) == value) {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(value) + `"`
+}
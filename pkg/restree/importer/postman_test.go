@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+	"github.com/kamil-koziol/restree/pkg/restree"
+)
+
+const validPostmanCollection = `{
+  "info": {"name": "Shop"},
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+  "event": [{"listen": "prerequest", "script": {"exec": ["pm.environment.set('a', 1);", ""]}}],
+  "variable": [{"key": "baseUrl", "value": "http://localhost"}, {"key": "unused", "value": "x", "disabled": true}],
+  "item": [
+    {
+      "name": "Users",
+      "item": [
+        {
+          "name": "Get user",
+          "request": {
+            "method": "GET",
+            "header": [{"key": "Accept", "value": "application/json"}, {"key": "X-Debug", "value": "1", "disabled": true}],
+            "url": {"raw": "{{baseUrl}}/users/:id", "variable": [{"key": "id", "value": "42"}]}
+          }
+        },
+        {
+          "name": "Create user",
+          "request": {
+            "method": "POST",
+            "header": [{"key": "Accept", "value": "application/json"}, {"key": "X-Request-ID", "value": "{{$guid}}"}],
+            "body": {"mode": "raw", "raw": "{\"name\": \"John\"}", "options": {"raw": {"language": "json"}}},
+            "url": "{{baseUrl}}/users"
+          }
+        }
+      ]
+    },
+    {
+      "name": "Login",
+      "request": {
+        "auth": {"type": "noauth"},
+        "method": "POST",
+        "body": {"mode": "urlencoded", "urlencoded": [{"key": "user", "value": "{{user}}"}, {"key": "pass word", "value": "a&b"}]},
+        "url": "{{baseUrl}}/login"
+      }
+    }
+  ]
+}`
+
+func TestPostman(t *testing.T) {
+	files, warnings, err := Postman([]byte(validPostmanCollection))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 0, len(warnings))
+
+	byPath := map[string]string{}
+	for _, f := range files {
+		byPath[f.Path] = f.Content
+	}
+	assert.Eq(t, 7, len(byPath))
+
+	assert.Eq(t, "baseUrl=http://localhost\n", byPath["_vars.env"])
+	assert.Eq(t, "Authorization: Bearer {{token}}\n", byPath["_headers.http"])
+	assert.Eq(t, "Accept: application/json\n", byPath["users/_headers.http"])
+	assert.Eq(t, `#!/bin/sh
+# Pre-request scripts imported from Postman, port them to shell.
+# Variables are printed as name=value lines, e.g. echo "token=$(./login.sh)".
+#
+# collection:
+# pm.environment.set('a', 1);
+`, byPath["_before.sh"])
+	assert.Eq(t, `### Get user
+GET {{baseUrl}}/users/{{id:-42}}
+`, byPath["users/get-user.http"])
+	assert.Eq(t, `### Create user
+POST {{baseUrl}}/users
+X-Request-ID: {{$uuid}}
+Content-Type: application/json
+
+{"name": "John"}
+`, byPath["users/create-user.http"])
+	assert.Eq(t, `### Login
+POST {{baseUrl}}/login
+-Authorization
+Content-Type: application/x-www-form-urlencoded
+
+user={{user}}&pass+word=a&b
+`, byPath["login.http"])
+}
+
+func TestPostmanWarnings(t *testing.T) {
+	_, warnings, err := Postman([]byte(`{"item": [{"name": "A", "event": [{"listen": "test", "script": {"exec": "pm.test()"}}],
+  "request": {"auth": {"type": "digest"}, "url": "http://localhost/{{$randomColor}}"}}]}`))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 3, len(warnings))
+
+	files, warnings, err := Postman([]byte(`{"item": [{"name": "Purge", "request": {"method": "purge", "url": "{{base}}/cache"}},
+  {"name": "Get", "request": {"url": "{{base}}/cache"}}]}`))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 1, len(warnings))
+	assert.Eq(t, "Purge: method PURGE is not supported, the request is skipped", warnings[0])
+	assert.Eq(t, 1, len(files))
+	assert.Eq(t, "get.http", files[0].Path)
+
+	_, _, err = Postman([]byte(`{"info": {}}`))
+	assert.Neq(t, nil, err)
+}
+
+func TestPostmanVariableReferences(t *testing.T) {
+	files, warnings, err := Postman([]byte(`{"variable": [
+  {"key": "base", "value": "{{host}}/api"},
+  {"key": "host", "value": "http://localhost"},
+  {"key": "users", "value": "{{base}}/users"},
+  {"key": "token", "value": "{{secret}}"},
+  {"key": "a", "value": "{{b}}"},
+  {"key": "b", "value": "{{a}}"}
+], "item": []}`))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, "base=http://localhost/api\nhost=http://localhost\nusers=http://localhost/api/users\ntoken={{secret}}\na={{b}}\nb={{a}}\n", files[0].Content)
+	assert.Eq(t, 5, len(warnings))
+	assert.Eq(t, "collection: variable base is resolved to http://localhost/api, it does not follow the variables it references", warnings[0])
+	assert.Eq(t, "collection: variable token references {{secret}}, env files are not expanded, set its value in .env", warnings[2])
+}
+
+func TestPostmanEnvironment(t *testing.T) {
+	profile, warnings, err := PostmanEnvironment([]byte(`{"name": "Staging EU", "values": [
+  {"key": "baseUrl", "value": "https://staging.example.com", "enabled": true},
+  {"key": "greeting", "value": "hello \"world\" #1", "enabled": true},
+  {"key": "old", "value": "x", "enabled": false}
+]}`))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 0, len(warnings))
+	assert.Eq(t, "_env/staging-eu.env", profile.Path)
+	assert.Eq(t, "baseUrl=https://staging.example.com\ngreeting=\"hello \\\"world\\\" #1\"\n", profile.Content)
+
+	env, err := restree.ParseEnvFile(strings.NewReader(profile.Content))
+	assert.Eq(t, nil, err)
+	assert.Eq(t, `hello "world" #1`, env.Variables["greeting"])
+}
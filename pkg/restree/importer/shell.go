@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitShellWords splits the command line into words like a POSIX shell without expansions
+//
+// Single quotes, double quotes, bash $'...' strings, backslash escapes and line continuations are supported.
+func SplitShellWords(s string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			i++
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated escape")
+			}
+			if runes[i] == '\n' {
+				continue
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i = end
+			inWord = true
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			end, value, err := ansiCString(runes, i+2)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(value)
+			i = end
+			inWord = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// ansiCString decodes the bash $'...' string starting at from, it returns the index of the closing quote
+func ansiCString(runes []rune, from int) (int, string, error) {
+	var b strings.Builder
+	escapes := map[rune]string{'n': "\n", 't': "\t", 'r': "\r", '\\': "\\", '\'': "'", '"': "\"", '0': "\x00"}
+
+	for i := from; i < len(runes); i++ {
+		switch runes[i] {
+		case '\'':
+			return i, b.String(), nil
+		case '\\':
+			i++
+			if i == len(runes) {
+				break
+			}
+			if e, ok := escapes[runes[i]]; ok {
+				b.WriteString(e)
+				continue
+			}
+			if runes[i] == 'u' && i+4 < len(runes) {
+				var code rune
+				if _, err := fmt.Sscanf(string(runes[i+1:i+5]), "%04x", &code); err == nil {
+					b.WriteRune(code)
+					i += 4
+					continue
+				}
+			}
+			b.WriteRune('\\')
+			b.WriteRune(runes[i])
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated $' quote")
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+	"github.com/kamil-koziol/restree/pkg/restree"
+)
+
+// minVariableLength is the length of the shortest value replaced by its variable, shorter values match by accident
+const minVariableLength = 4
+
+// StripInherited removes the headers of the request that are already given by the inherited headers
+//
+// A header is given when an inherited header has the same name and either the same value
+// or a value with a placeholder, e.g. `Authorization: Bearer {{token}}`.
+func StripInherited(req *httpparser.HTTPRequest, inherited httpparser.HTTPHeaders) {
+	headers := httpparser.HTTPHeaders{}
+	for _, h := range req.Headers {
+		given := slices.ContainsFunc(inherited, func(i httpparser.HTTPHeader) bool {
+			return strings.EqualFold(i.Name, h.Name) && (i.Value == h.Value || strings.Contains(i.Value, "{{"))
+		})
+		if !given {
+			headers = append(headers, h)
+		}
+	}
+	req.Headers = headers
+}
+
+// Templatize replaces the values of the variables in the request with their placeholders
+//
+// Only whole values are replaced, so a value inside another word is kept:
+//
+//   - the base of the URL, e.g. {{host}}/users, and whole path segments and query values
+//   - whole header values and their whole words, e.g. Bearer {{token}}
+//   - string values of JSON bodies and values of form bodies
+//   - values of multipart parts
+//
+// Values shorter than 4 characters are not replaced.
+func Templatize(req *httpparser.HTTPRequest, variables restree.Variables) {
+	names := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		value := variables[name]
+		if _, ok := names[value]; !ok && len(value) >= minVariableLength {
+			names[value] = name
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	t := templatizer(names)
+
+	req.URL = t.url(req.URL)
+	for i := range req.Headers {
+		req.Headers[i].Value = t.words(req.Headers[i].Value)
+	}
+	contentType, _ := req.Headers.Get("Content-Type")
+	switch {
+	case json.Valid([]byte(req.Body)):
+		req.Body = t.json(req.Body)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		req.Body = t.query(req.Body)
+	}
+	for i := range req.Parts {
+		req.Parts[i].Value = t.value(req.Parts[i].Value)
+	}
+}
+
+// templatizer maps the values of the variables to their names
+type templatizer map[string]string
+
+var jsonStringRegexp = regexp.MustCompile(`"(?:[^"\\]|\\.)*"(\s*:)?`)
+
+// value returns the placeholder of the whole value, or the value when it is not a variable
+func (t templatizer) value(s string) string {
+	if name, ok := t[s]; ok {
+		return "{{" + name + "}}"
+	}
+	return s
+}
+
+// words replaces the whole value or its space separated words
+func (t templatizer) words(s string) string {
+	if _, ok := t[s]; ok {
+		return t.value(s)
+	}
+	words := strings.Split(s, " ")
+	for i := range words {
+		words[i] = t.value(words[i])
+	}
+	return strings.Join(words, " ")
+}
+
+// url replaces the longest base of the URL ending at a path boundary, the path segments and the query values
+func (t templatizer) url(u string) string {
+	base, query, hasQuery := strings.Cut(u, "?")
+
+	prefix := ""
+	for value := range t {
+		if len(value) > len(prefix) && (base == value || strings.HasPrefix(base, strings.TrimSuffix(value, "/")+"/")) {
+			prefix = value
+		}
+	}
+	rest := base[len(prefix):]
+	if prefix != "" {
+		prefix = t.value(prefix)
+	} else if _, after, ok := strings.Cut(base, "://"); ok {
+		// the scheme and the host are not a path segment
+		end := len(base) - len(after)
+		if i := strings.Index(after, "/"); i >= 0 {
+			end += i
+		} else {
+			end = len(base)
+		}
+		prefix, rest = base[:end], base[end:]
+	}
+
+	segments := strings.Split(rest, "/")
+	for i := range segments {
+		segments[i] = t.value(segments[i])
+	}
+	result := prefix + strings.Join(segments, "/")
+	if hasQuery {
+		result += "?" + t.query(query)
+	}
+	return result
+}
+
+// query replaces the values of the name=value pairs separated by &
+func (t templatizer) query(s string) string {
+	pairs := strings.Split(s, "&")
+	for i, pair := range pairs {
+		if name, value, ok := strings.Cut(pair, "="); ok {
+			pairs[i] = name + "=" + t.value(value)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// json replaces the string values of the JSON document, keys are kept
+func (t templatizer) json(s string) string {
+	return jsonStringRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasSuffix(m, ":") {
+			return m
+		}
+		var value string
+		if err := json.Unmarshal([]byte(m), &value); err != nil {
+			return m
+		}
+		if name, ok := t[value]; ok {
+			return `"{{` + name + `}}"`
+		}
+		return m
+	})
+}
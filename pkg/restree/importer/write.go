@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// GeneratedManifestFileName records the checksums of the generated files to detect hand edits
+const GeneratedManifestFileName = "_generated.json"
+
+// File is a file of the generated tree
+type File struct {
+	// Path is the slash-separated path relative to the root of the tree
+	Path    string
+	Content string
+}
+
+// WriteReport lists the paths of the files by the outcome of [WriteFiles]
+type WriteReport struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+	// Kept are the files edited by hand since they were generated, they are not overwritten
+	Kept []string
+}
+
+// WriteFiles writes the generated files to dir without overwriting hand edits
+//
+// A file is overwritten only when its content still matches the checksum recorded in
+// [GeneratedManifestFileName] when it was last generated.
+func WriteFiles(dir string, files []File) (*WriteReport, error) {
+	manifestPath := filepath.Join(dir, GeneratedManifestFileName)
+	manifest := map[string]string{}
+	data, err := os.ReadFile(manifestPath)
+	if err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", manifestPath, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to read %s: %w", manifestPath, err)
+	}
+
+	report := &WriteReport{}
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+
+		existing, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			report.Created = append(report.Created, f.Path)
+		case err != nil:
+			return nil, fmt.Errorf("unable to read %s: %w", path, err)
+		case string(existing) == f.Content:
+			report.Unchanged = append(report.Unchanged, f.Path)
+			manifest[f.Path] = checksum(f.Content)
+			continue
+		case manifest[f.Path] == checksum(string(existing)):
+			report.Updated = append(report.Updated, f.Path)
+		default:
+			report.Kept = append(report.Kept, f.Path)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("unable to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(f.Content), 0o644); err != nil {
+			return nil, fmt.Errorf("unable to write %s: %w", path, err)
+		}
+		manifest[f.Path] = checksum(f.Content)
+	}
+
+	data, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create directory: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("unable to write %s: %w", manifestPath, err)
+	}
+
+	return report, nil
+}
+
+// uniquePath returns the slash-separated path of base with the extension, numbered when it is already in paths
+func uniquePath(paths map[string]bool, base string, ext string) string {
+	result := base + ext
+	for i := 2; paths[result]; i++ {
+		result = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	paths[result] = true
+	return result
+}
+
+// requestSource renders the request with its name and the comment lines before the request line
+func requestSource(name string, comment string, req *httpparser.HTTPRequest) string {
+	var b strings.Builder
+	if name != "" {
+		fmt.Fprintf(&b, "### %s\n", name)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(comment), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(&b, "# %s\n", line)
+		}
+	}
+	b.WriteString(req.Source())
+	return b.String()
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
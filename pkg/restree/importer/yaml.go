@@ -0,0 +1,488 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses the subset of YAML used by API specifications into the types of [encoding/json]
+//
+// Supported are block mappings and sequences, flow collections, plain and quoted scalars,
+// block scalars and comments. Anchors, aliases, tags and multiple documents are not supported.
+func parseYAML(data string) (any, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		p.lines = append(p.lines, yamlLine{
+			number: i + 1,
+			indent: len(raw) - len(strings.TrimLeft(raw, " ")),
+			text:   strings.TrimSpace(raw),
+			raw:    raw,
+		})
+	}
+
+	p.skipBlank()
+	if p.i < len(p.lines) && p.lines[p.i].text == "---" {
+		p.i++
+		p.skipBlank()
+	}
+	if p.i == len(p.lines) {
+		return nil, nil
+	}
+
+	value, err := p.parseNode(p.lines[p.i].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+	if p.i < len(p.lines) && p.lines[p.i].text != "..." {
+		return nil, p.errorf("unexpected content %q", p.lines[p.i].text)
+	}
+	return value, nil
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+	raw    string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	line := len(p.lines)
+	if p.i < len(p.lines) {
+		line = p.lines[p.i].number
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipBlank skips the empty and comment lines
+func (p *yamlParser) skipBlank() {
+	for p.i < len(p.lines) && (p.lines[p.i].text == "" || strings.HasPrefix(p.lines[p.i].text, "#")) {
+		p.i++
+	}
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseNode(indent int) (any, error) {
+	line := p.lines[p.i]
+	switch {
+	case isSequenceItem(line.text):
+		return p.parseSequence(indent)
+	case splitKey(line.text) >= 0:
+		return p.parseMapping(indent)
+	default:
+		p.i++
+		return p.parseInline(line.text)
+	}
+}
+
+func (p *yamlParser) parseMapping(indent int) (any, error) {
+	mapping := map[string]any{}
+
+	for {
+		p.skipBlank()
+		if p.i == len(p.lines) || p.lines[p.i].indent < indent {
+			return mapping, nil
+		}
+		line := p.lines[p.i]
+		if line.indent > indent || isSequenceItem(line.text) {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		colon := splitKey(line.text)
+		if colon < 0 {
+			return nil, p.errorf("expected a key: %q", line.text)
+		}
+		key, err := parseKey(line.text[:colon])
+		if err != nil {
+			return nil, p.errorf("%s", err)
+		}
+		rest := strings.TrimSpace(stripYAMLComment(line.text[colon+1:]))
+		p.i++
+
+		value, err := p.parseValue(indent, rest)
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = value
+	}
+}
+
+func (p *yamlParser) parseSequence(indent int) (any, error) {
+	sequence := []any{}
+
+	for {
+		p.skipBlank()
+		if p.i == len(p.lines) || p.lines[p.i].indent < indent {
+			return sequence, nil
+		}
+		line := p.lines[p.i]
+		if line.indent > indent || !isSequenceItem(line.text) {
+			return sequence, nil
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			p.i++
+			p.skipBlank()
+			if p.i == len(p.lines) || p.lines[p.i].indent <= indent {
+				sequence = append(sequence, nil)
+				continue
+			}
+			value, err := p.parseNode(p.lines[p.i].indent)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+			continue
+		}
+
+		if isSequenceItem(rest) || splitKey(rest) >= 0 {
+			// the item is a nested collection starting on the same line, e.g. `- name: id`
+			itemIndent := line.indent + len(line.text) - len(rest)
+			p.lines[p.i] = yamlLine{number: line.number, indent: itemIndent, text: rest, raw: line.raw}
+			value, err := p.parseNode(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+			continue
+		}
+
+		p.i++
+		value, err := p.parseValue(indent, stripYAMLComment(rest))
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, value)
+	}
+}
+
+// parseValue parses the value following a key or a sequence dash on the line with the indent
+func (p *yamlParser) parseValue(indent int, rest string) (any, error) {
+	switch {
+	case rest == "":
+		p.skipBlank()
+		if p.i == len(p.lines) {
+			return nil, nil
+		}
+		next := p.lines[p.i]
+		if next.indent > indent || (next.indent == indent && isSequenceItem(next.text)) {
+			return p.parseNode(next.indent)
+		}
+		return nil, nil
+	case rest[0] == '|' || rest[0] == '>':
+		return p.parseBlockScalar(indent, rest), nil
+	case rest[0] == '&' || rest[0] == '*' || rest[0] == '!':
+		return nil, p.errorf("anchors, aliases and tags are not supported")
+	case rest[0] == '[' || rest[0] == '{':
+		// flow collections may span multiple lines
+		for !flowClosed(rest) && p.i < len(p.lines) {
+			rest += " " + stripYAMLComment(p.lines[p.i].text)
+			p.i++
+		}
+		return p.parseInline(rest)
+	default:
+		// plain scalars may continue on more indented lines
+		for p.i < len(p.lines) && p.lines[p.i].indent > indent && p.lines[p.i].text != "" &&
+			rest[0] != '"' && rest[0] != '\'' && !strings.HasPrefix(p.lines[p.i].text, "#") {
+			if splitKey(p.lines[p.i].text) >= 0 {
+				return nil, p.errorf("unexpected mapping in a plain scalar")
+			}
+			rest += " " + stripYAMLComment(p.lines[p.i].text)
+			p.i++
+		}
+		return p.parseInline(rest)
+	}
+}
+
+// parseBlockScalar parses the literal (|) or folded (>) scalar following the line with the indent
+func (p *yamlParser) parseBlockScalar(indent int, header string) string {
+	lines := []string{}
+	blockIndent := -1
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		if line.text != "" {
+			if line.indent <= indent {
+				break
+			}
+			if blockIndent < 0 {
+				blockIndent = line.indent
+			}
+		}
+		if line.text == "" {
+			lines = append(lines, "")
+		} else {
+			lines = append(lines, line.raw[min(blockIndent, line.indent):])
+		}
+		p.i++
+	}
+
+	// trailing empty lines are not part of the content
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var value string
+	if header[0] == '|' {
+		value = strings.Join(lines, "\n")
+	} else {
+		value = foldLines(lines)
+	}
+
+	switch {
+	case strings.Contains(header, "-"):
+		return value
+	case strings.Contains(header, "+"):
+		return value + strings.Repeat("\n", trailing+1)
+	default:
+		return value + "\n"
+	}
+}
+
+func foldLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		switch {
+		case i == 0:
+		case line == "" || lines[i-1] == "" || strings.HasPrefix(line, " "):
+			b.WriteString("\n")
+		default:
+			b.WriteString(" ")
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func (p *yamlParser) parseInline(s string) (any, error) {
+	value, rest, err := parseFlow(strings.TrimSpace(s), false)
+	if err != nil {
+		return nil, p.errorf("%s", err)
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, p.errorf("unexpected %q", rest)
+	}
+	return value, nil
+}
+
+// parseFlow parses the flow value at the start of s and returns the remaining text
+//
+// Inside flow collections plain scalars end at the flow indicators.
+func parseFlow(s string, inCollection bool) (any, string, error) {
+	s = strings.TrimLeft(s, " ")
+	if s == "" {
+		return nil, "", nil
+	}
+
+	switch s[0] {
+	case '[':
+		items := []any{}
+		s = strings.TrimLeft(s[1:], " ")
+		for {
+			if strings.HasPrefix(s, "]") {
+				return items, s[1:], nil
+			}
+			item, rest, err := parseFlow(s, true)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, item)
+			s = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(s, ",") {
+				s = strings.TrimLeft(s[1:], " ")
+			} else if !strings.HasPrefix(s, "]") {
+				return nil, "", fmt.Errorf("unterminated flow sequence")
+			}
+		}
+	case '{':
+		mapping := map[string]any{}
+		s = strings.TrimLeft(s[1:], " ")
+		for {
+			if strings.HasPrefix(s, "}") {
+				return mapping, s[1:], nil
+			}
+			rawKey, rest, err := parseFlow(s, true)
+			if err != nil {
+				return nil, "", err
+			}
+			rest = strings.TrimLeft(rest, " ")
+			if !strings.HasPrefix(rest, ":") {
+				return nil, "", fmt.Errorf("expected : in flow mapping")
+			}
+			value, rest, err := parseFlow(rest[1:], true)
+			if err != nil {
+				return nil, "", err
+			}
+			mapping[fmt.Sprint(rawKey)] = value
+			s = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(s, ",") {
+				s = strings.TrimLeft(s[1:], " ")
+			} else if !strings.HasPrefix(s, "}") {
+				return nil, "", fmt.Errorf("unterminated flow mapping")
+			}
+		}
+	case '"':
+		end := 1
+		for ; end < len(s) && s[end] != '"'; end++ {
+			if s[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(s) {
+			return nil, "", fmt.Errorf("unterminated double quoted string")
+		}
+		var value string
+		if err := json.Unmarshal([]byte(s[:end+1]), &value); err != nil {
+			return nil, "", fmt.Errorf("invalid double quoted string: %w", err)
+		}
+		return value, s[end+1:], nil
+	case '\'':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return b.String(), s[i+1:], nil
+		}
+		return nil, "", fmt.Errorf("unterminated single quoted string")
+	}
+
+	end := len(s)
+	if inCollection {
+		for i := 0; i < len(s); i++ {
+			if strings.ContainsRune(",]}", rune(s[i])) || (s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ')) {
+				end = i
+				break
+			}
+		}
+	}
+	return plainScalar(strings.TrimSpace(s[:end])), s[end:], nil
+}
+
+// plainScalar resolves the type of the unquoted scalar
+func plainScalar(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil && !strings.HasPrefix(s, "0x") {
+		return n
+	}
+	return s
+}
+
+// splitKey returns the index of the colon separating the key of a mapping entry, -1 when the text is not an entry
+func splitKey(text string) int {
+	if text == "" || strings.ContainsRune("[{#", rune(text[0])) {
+		return -1
+	}
+
+	var quote byte
+	if text[0] == '"' || text[0] == '\'' {
+		quote = text[0]
+	}
+	for i := 1; i < len(text); i++ {
+		if quote != 0 {
+			if text[i] == '\\' && quote == '"' {
+				i++
+			} else if text[i] == quote {
+				quote = 0
+			}
+			continue
+		}
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return i
+		}
+	}
+	if text[0] != '"' && text[0] != '\'' && text[len(text)-1] == ':' {
+		return len(text) - 1
+	}
+	return -1
+}
+
+func parseKey(s string) (string, error) {
+	value, rest, err := parseFlow(s, false)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return "", fmt.Errorf("invalid key %q", s)
+	}
+	if value == nil {
+		return strings.TrimSpace(s), nil
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	// numeric and boolean keys, e.g. response codes, are kept as written
+	return strings.TrimSpace(s), nil
+}
+
+// stripYAMLComment removes the comment outside of quotes from the text
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch {
+		case quote != 0:
+			if text[i] == '\\' && quote == '"' {
+				i++
+			} else if text[i] == quote {
+				quote = 0
+			}
+		case text[i] == '"' || text[i] == '\'':
+			if i == 0 || text[i-1] == ' ' || strings.ContainsRune("[{,:", rune(text[i-1])) {
+				quote = text[i]
+			}
+		case text[i] == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimRight(text[:i], " ")
+		}
+	}
+	return text
+}
+
+// flowClosed reports whether the brackets of the flow collection are balanced
+func flowClosed(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '[' || s[i] == '{':
+			depth++
+		case s[i] == ']' || s[i] == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
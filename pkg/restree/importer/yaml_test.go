@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func TestParseYAML(t *testing.T) {
+	value, err := parseYAML(`---
+# comment
+openapi: 3.0.0
+info:
+  title: "Pet \"store\""   # trailing comment
+  description: |
+    Line one
+    Line two
+
+  summary: >-
+    folded
+    text
+servers:
+  - url: http://localhost:8080/v1
+    description: local
+tags: [pets, 'store''s', {name: admin}]
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+      - name: id
+        in: path
+        required: true
+      - in: query
+        name: limit
+        schema: {type: integer, default: 10}
+      responses:
+        '200':
+          description: ok
+        404:
+          description: not found
+empty:
+list:
+  -
+    nested: true
+  - - a
+    - b
+url: http://example.com/#anchor
+`)
+	assert.Eq(t, nil, err)
+
+	b, err := json.Marshal(value)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, `{"empty":null,"info":{"description":"Line one\nLine two\n","summary":"folded text","title":"Pet \"store\""},"list":[{"nested":true},["a","b"]],"openapi":"3.0.0","paths":{"/pets/{id}":{"get":{"parameters":[{"in":"path","name":"id","required":true},{"in":"query","name":"limit","schema":{"default":10,"type":"integer"}}],"responses":{"200":{"description":"ok"},"404":{"description":"not found"}}}}},"servers":[{"description":"local","url":"http://localhost:8080/v1"}],"tags":["pets","store's",{"name":"admin"}],"url":"http://example.com/#anchor"}`, string(b))
+}
+
+func TestParseYAMLInvalid(t *testing.T) {
+	tests := []string{
+		"a: &anchor 1\nb: *anchor\n",
+		"a: 'unterminated\n",
+		"a: 1\n  b: 2\n",
+		"a: [1, 2\n",
+	}
+
+	for _, tt := range tests {
+		_, err := parseYAML(tt)
+		assert.Neq(t, nil, err)
+	}
+}
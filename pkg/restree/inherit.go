@@ -0,0 +1,64 @@
+package restree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// dirLevels returns the slash-separated directories from the root of fsys to dir
+func dirLevels(dir string) []string {
+	if dir == "." || dir == "" {
+		return []string{"."}
+	}
+	return append(dirLevels(path.Dir(dir)), dir)
+}
+
+// InheritedHeaders returns the unexpanded headers given by the `_headers.http` files from the root of fsys to dir
+func InheritedHeaders(fsys fs.FS, dir string) (httpparser.HTTPHeaders, error) {
+	headers := httpparser.HTTPHeaders{}
+	for _, level := range dirLevels(dir) {
+		f, err := fsys.Open(path.Join(level, HeadersFileName))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to open headers file: %w", err)
+		}
+
+		parsed, err := httpparser.ParseHeadersFile(f)
+		f.Close() //nolint:errcheck
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path.Join(level, HeadersFileName), err)
+		}
+		headers = headers.Merge(parsed)
+	}
+	return headers, nil
+}
+
+// TreeVariables returns the variables of the env files and the env profile from the root of fsys to dir
+//
+// The scripts are not run.
+func TreeVariables(fsys fs.FS, dir string, env string) (Variables, error) {
+	variables := Variables{}
+	for _, level := range dirLevels(dir) {
+		names := VarsFileNames
+		if env != "" {
+			names = append(names[:len(names):len(names)], path.Join(EnvDirName, env+".env"))
+		}
+		for _, name := range names {
+			envFile, err := readEnvFileFS(fsys, path.Join(level, name))
+			if err != nil {
+				return nil, err
+			}
+			if envFile != nil {
+				maps.Copy(variables, envFile.Variables)
+			}
+		}
+	}
+	return variables, nil
+}
@@ -0,0 +1,77 @@
+package restree
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// quoteEscaper escapes the quoted parameters of Content-Disposition the way [multipart.Writer.CreateFormFile] does
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// encodeMultipart encodes the parts as a multipart/form-data body
+//
+// File parts are read through fsys relative to dir and their File is replaced with the absolute path.
+// It returns the Content-Type with the generated boundary and the body.
+func encodeMultipart(fsys fs.FS, root string, dir string, parts []httpparser.Part) (string, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(dynamic.Boundary()); err != nil {
+		return "", "", fmt.Errorf("unable to set boundary: %w", err)
+	}
+
+	for i := range parts {
+		part := &parts[i]
+		value := part.Value
+
+		if part.File != "" {
+			filePath, content, err := readBodyFileFS(fsys, root, dir, part.File)
+			if err != nil {
+				return "", "", fmt.Errorf("part %s: %w", part.Name, err)
+			}
+			part.File = filePath
+			value = content
+
+			if part.Filename == "" {
+				part.Filename = filepath.Base(filePath)
+			}
+			if part.ContentType == "" {
+				part.ContentType = mime.TypeByExtension(filepath.Ext(filePath))
+			}
+			if part.ContentType == "" {
+				part.ContentType = "application/octet-stream"
+			}
+		}
+
+		disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name))
+		if part.Filename != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(part.Filename))
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", disposition)
+		if part.ContentType != "" {
+			header.Set("Content-Type", part.ContentType)
+		}
+
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to create part %s: %w", part.Name, err)
+		}
+		if _, err := pw.Write([]byte(value)); err != nil {
+			return "", "", fmt.Errorf("unable to write part %s: %w", part.Name, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", "", fmt.Errorf("unable to close multipart body: %w", err)
+	}
+
+	return w.FormDataContentType(), buf.String(), nil
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSONLines writes a JSON object per case followed by a summary object
+type JSONLines struct{}
+
+type jsonCase struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	File       string   `json:"file"`
+	Name       string   `json:"name,omitempty"`
+	Method     string   `json:"method,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	Status     int      `json:"status,omitempty"`
+	DurationMS int64    `json:"duration_ms"`
+	Passed     bool     `json:"passed"`
+	Failures   []string `json:"failures,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type jsonSummary struct {
+	Type       string `json:"type"`
+	Passed     int    `json:"passed"`
+	Failed     int    `json:"failed"`
+	Total      int    `json:"total"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+func (JSONLines) Report(w io.Writer, cases []Case, duration time.Duration) error {
+	enc := json.NewEncoder(w)
+
+	for _, c := range cases {
+		err := enc.Encode(jsonCase{
+			Type:       "case",
+			ID:         c.ID,
+			File:       c.File,
+			Name:       c.Name,
+			Method:     c.Method,
+			URL:        c.URL,
+			Status:     c.Status,
+			DurationMS: c.Duration.Milliseconds(),
+			Passed:     c.Passed(),
+			Failures:   c.Failures,
+			Error:      c.Error,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	failed := Failed(cases)
+	return enc.Encode(jsonSummary{
+		Type:       "summary",
+		Passed:     len(cases) - failed,
+		Failed:     failed,
+		Total:      len(cases),
+		DurationMS: duration.Milliseconds(),
+	})
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// JUnit writes JUnit XML with a test suite per .http file and a test case per request
+type JUnit struct{}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (JUnit) Report(w io.Writer, cases []Case, duration time.Duration) error {
+	root := junitTestSuites{
+		Time: seconds(duration),
+	}
+
+	suiteIdx := map[string]int{}
+	suiteDurations := []time.Duration{}
+
+	for _, c := range cases {
+		idx, ok := suiteIdx[c.File]
+		if !ok {
+			idx = len(root.Suites)
+			suiteIdx[c.File] = idx
+			root.Suites = append(root.Suites, junitTestSuite{Name: c.File})
+			suiteDurations = append(suiteDurations, 0)
+		}
+		suite := &root.Suites[idx]
+
+		name := c.ID
+		if c.Name != "" {
+			name = c.Name
+		}
+		tc := junitTestCase{
+			Name:      name,
+			Classname: c.File,
+			Time:      seconds(c.Duration),
+			SystemOut: c.Request(),
+		}
+
+		switch {
+		case c.Error != "":
+			tc.Error = &junitMessage{Message: c.Error, Text: c.Error}
+			suite.Errors++
+			root.Errors++
+		case len(c.Failures) > 0:
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d assertions failed", len(c.Failures)),
+				Text:    strings.Join(c.Failures, "\n"),
+			}
+			suite.Failures++
+			root.Failures++
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		root.Tests++
+		suiteDurations[idx] += c.Duration
+		suite.Time = seconds(suiteDurations[idx])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Case is a single executed request
+type Case struct {
+	// ID identifies the request in the `path#index` format
+	ID string
+	// File is the slash-separated path of the .http file
+	File string
+	// Name is the optional name of the request
+	Name     string
+	Method   string
+	URL      string
+	Status   int
+	Duration time.Duration
+	// Failures are the messages of failed assertions
+	Failures []string
+	// Error is set when the request could not be built or executed
+	Error string
+}
+
+func (c Case) Passed() bool {
+	return c.Error == "" && len(c.Failures) == 0
+}
+
+// Title returns the ID and the name of the case when it is set
+func (c Case) Title() string {
+	if c.Name == "" {
+		return c.ID
+	}
+	return fmt.Sprintf("%s (%s)", c.ID, c.Name)
+}
+
+// Request returns the request summary in the `METHOD URL` format
+func (c Case) Request() string {
+	return strings.TrimSpace(c.Method + " " + c.URL)
+}
+
+// Reporter writes the results of a test run
+type Reporter interface {
+	Report(w io.Writer, cases []Case, duration time.Duration) error
+}
+
+var reporters = map[string]Reporter{
+	"text":  Text{},
+	"junit": JUnit{},
+	"tap":   TAP{},
+	"json":  JSONLines{},
+}
+
+// Names returns the names of available reporters
+func Names() []string {
+	names := []string{}
+	for name := range reporters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New returns the reporter with the given name
+func New(name string) (Reporter, error) {
+	r, ok := reporters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown reporter %q, available: %s", name, strings.Join(Names(), ", "))
+	}
+	return r, nil
+}
+
+// Failed returns the number of failed cases
+func Failed(cases []Case) int {
+	failed := 0
+	for _, c := range cases {
+		if !c.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
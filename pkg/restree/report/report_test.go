@@ -0,0 +1,66 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func validCases() []Case {
+	return []Case{
+		{ID: "users/get.http#1", File: "users/get.http", Method: "GET", URL: "http://localhost/users", Status: 200, Duration: time.Millisecond},
+		{ID: "users/get.http#2", File: "users/get.http", Name: "missing", Failures: []string{"status == 404: got 200"}},
+		{ID: "broken.http", File: "broken.http", Error: "failed to parse"},
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range Names() {
+		_, err := New(name)
+		assert.Eq(t, nil, err)
+	}
+	_, err := New("unknown")
+	assert.Neq(t, nil, err)
+}
+
+func TestJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	err := JUnit{}.Report(&buf, validCases(), time.Second)
+	assert.Eq(t, nil, err)
+
+	var suites junitTestSuites
+	err = xml.Unmarshal(buf.Bytes(), &suites)
+	assert.Eq(t, nil, err)
+	assert.Eq(t, 3, suites.Tests)
+	assert.Eq(t, 1, suites.Failures)
+	assert.Eq(t, 1, suites.Errors)
+	assert.Eq(t, 2, len(suites.Suites))
+	assert.Eq(t, 2, len(suites.Suites[0].Cases))
+	assert.Eq(t, "missing", suites.Suites[0].Cases[1].Name)
+}
+
+func TestTAP(t *testing.T) {
+	var buf bytes.Buffer
+	err := TAP{}.Report(&buf, validCases(), time.Second)
+	assert.Eq(t, nil, err)
+
+	out := buf.String()
+	assert.Assert(t, strings.Contains(out, "1..3\n"), out)
+	assert.Assert(t, strings.Contains(out, "ok 1 - users/get.http#1\n"), out)
+	assert.Assert(t, strings.Contains(out, "not ok 2 - users/get.http#2 (missing)\n"), out)
+	assert.Assert(t, strings.Contains(out, "not ok 3 - broken.http\n"), out)
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	err := JSONLines{}.Report(&buf, validCases(), time.Second)
+	assert.Eq(t, nil, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Eq(t, 4, len(lines))
+	assert.Assert(t, strings.Contains(lines[3], `"failed":2`), lines[3])
+}
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// TAP writes the results in the Test Anything Protocol version 13
+type TAP struct{}
+
+func (TAP) Report(w io.Writer, cases []Case, _ time.Duration) error {
+	var b strings.Builder
+
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(cases))
+
+	for i, c := range cases {
+		status := "ok"
+		if !c.Passed() {
+			status = "not ok"
+		}
+		fmt.Fprintf(&b, "%s %d - %s\n", status, i+1, c.Title())
+
+		if c.Passed() {
+			continue
+		}
+		b.WriteString("  ---\n")
+		fmt.Fprintf(&b, "  request: %q\n", c.Request())
+		fmt.Fprintf(&b, "  duration_ms: %d\n", c.Duration.Milliseconds())
+		if c.Error != "" {
+			fmt.Fprintf(&b, "  error: %q\n", c.Error)
+		}
+		if len(c.Failures) > 0 {
+			b.WriteString("  failures:\n")
+			for _, f := range c.Failures {
+				fmt.Fprintf(&b, "    - %q\n", f)
+			}
+		}
+		b.WriteString("  ...\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Text writes a human readable summary
+type Text struct{}
+
+func (Text) Report(w io.Writer, cases []Case, duration time.Duration) error {
+	failed := Failed(cases)
+
+	if failed > 0 {
+		if _, err := fmt.Fprintln(w, "Failures:"); err != nil {
+			return err
+		}
+		for _, c := range cases {
+			if c.Passed() {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "  %s\n", c.Title()); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d passed, %d failed, %d total in %s\n", len(cases)-failed, failed, len(cases), duration.Round(time.Millisecond))
+	return err
+}
@@ -0,0 +1,28 @@
+package restree
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// RootMarkerFileNames mark the root directory of a tree
+var RootMarkerFileNames = []string{".restree", "restree.toml"}
+
+// FindRoot walks up from the directory to the closest directory containing a root marker
+//
+// It reports false when no marker is found.
+func FindRoot(dir string) (string, bool) {
+	for {
+		for _, name := range RootMarkerFileNames {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
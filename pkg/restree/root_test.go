@@ -0,0 +1,42 @@
+package restree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func TestFindRoot(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"api/.restree":              "",
+		"api/users/admin/get.http":  "GET http://localhost/\n",
+		"other/restree.toml":        "",
+		"other/nested/list.http":    "GET http://localhost/\n",
+		"unmarked/nested/list.http": "GET http://localhost/\n",
+	})
+
+	root, ok := FindRoot(filepath.Join(dir, "api", "users", "admin"))
+	assert.Eq(t, true, ok)
+	assert.Eq(t, filepath.Join(dir, "api"), root)
+
+	root, ok = FindRoot(filepath.Join(dir, "other", "nested"))
+	assert.Eq(t, true, ok)
+	assert.Eq(t, filepath.Join(dir, "other"), root)
+
+	root, ok = FindRoot(filepath.Join(dir, "unmarked", "nested"))
+	assert.Eq(t, false, ok)
+	assert.Eq(t, "", root)
+}
+
+func TestRecursiveReadFSOutsideRoot(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"api/get.http":    "GET http://localhost/\n",
+		"api-v2/get.http": "GET http://localhost/\n",
+	})
+	root := filepath.Join(dir, "api")
+
+	_, err := RecursiveReadFS(os.DirFS(root), root, filepath.Join(dir, "api-v2", "get.http"), Variables{}, RecursiveReadOpts{})
+	assert.Neq(t, nil, err)
+}
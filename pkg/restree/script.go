@@ -0,0 +1,241 @@
+package restree
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// scriptContext describes the request a script is decorating
+//
+// It is passed to the scripts as environment variables:
+//
+//	<variable>=<value>      every variable accumulated so far
+//	RESTREE_ROOT            absolute path of the root directory
+//	RESTREE_DIR             absolute path of the directory of the script
+//	RESTREE_TARGET          absolute path of the target .http file
+//	RESTREE_REQUEST         request selector, empty when not set
+//	RESTREE_METHOD          method of the request
+//	RESTREE_URL             URL of the request as written in the file, before variable expansion
+//	RESTREE_HEADER_<NAME>   headers merged so far, name upper-cased with - replaced by _, repeated values joined with ", "
+//	RESTREE_HEADERS_FILE    path of a JSON file with the headers merged so far, [{"name": "...", "value": "..."}]
+type scriptContext struct {
+	Root    string
+	Dir     string
+	Target  string
+	Request string
+	Method  string
+	URL     string
+	Headers httpparser.HTTPHeaders
+}
+
+type scriptHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// environ returns the environment of the script and a cleanup function removing the headers file
+func (c scriptContext) environ(variables Variables) ([]string, func(), error) {
+	env := os.Environ()
+	for k, v := range variables {
+		env = append(env, k+"="+v)
+	}
+
+	env = append(env,
+		"RESTREE_ROOT="+c.Root,
+		"RESTREE_DIR="+c.Dir,
+		"RESTREE_TARGET="+c.Target,
+		"RESTREE_REQUEST="+c.Request,
+		"RESTREE_METHOD="+c.Method,
+		"RESTREE_URL="+c.URL,
+	)
+
+	headers := []scriptHeader{}
+	seen := map[string]bool{}
+	for _, h := range c.Headers {
+		headers = append(headers, scriptHeader{Name: h.Name, Value: h.Value})
+
+		key := headerEnvName(h.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		env = append(env, key+"="+strings.Join(c.Headers.Values(h.Name), ", "))
+	}
+
+	headersFile, err := writeTempFile("restree-headers-*.json", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(headers)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot write headers file: %w", err)
+	}
+	env = append(env, "RESTREE_HEADERS_FILE="+headersFile)
+
+	return env, func() { os.Remove(headersFile) }, nil //nolint:errcheck
+}
+
+// writeTempFile creates a temporary file with the content written by write and returns its path
+func writeTempFile(pattern string, write func(w io.Writer) error) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+
+	if err := write(f); err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// headerEnvName converts the header name to the RESTREE_HEADER_<NAME> variable name
+func headerEnvName(name string) string {
+	return "RESTREE_HEADER_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// runScript executes the script in place with the working directory set to its directory
+//
+// The interpreter is taken from the shebang line, scripts without one are run with the ScriptShell of the opts.
+// Stdout is returned, stderr is forwarded to the stderr of the process.
+// The script is interrupted after the ScriptTimeout of the opts, when it is not zero.
+func runScript(scriptPath string, env []string, stdin io.Reader, opts RecursiveReadOpts) (string, error) {
+	name, args, err := scriptInterpreter(scriptPath, opts.ScriptShell)
+	if err != nil {
+		return "", err
+	}
+
+	timeout := opts.ScriptTimeout
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, append(args, scriptPath)...)
+	cmd.Dir = filepath.Dir(scriptPath)
+	cmd.Env = env
+	cmd.Stdin = stdin
+	cmd.Stderr = os.Stderr
+	// give the script a chance to clean up before it is killed
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = scriptKillDelay
+
+	var stdoutBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("script timed out after %s", timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("script execution error: %w", err)
+	}
+
+	return stdoutBuf.String(), nil
+}
+
+// runCachedScript runs the script unless its output is in the cache, it reports whether the output was cached
+func runCachedScript(scriptPath string, env []string, opts RecursiveReadOpts) (string, bool, error) {
+	if opts.ScriptCache == nil {
+		stdout, err := runScript(scriptPath, env, opts.ScriptStdin, opts)
+		return stdout, false, err
+	}
+
+	script, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return "", false, fmt.Errorf("cannot read script: %w", err)
+	}
+	ttl, err := scriptCacheTTL(script)
+	if err != nil {
+		return "", false, err
+	}
+
+	// scripts without a shebang line depend on the configured shell
+	interpreter, _, err := scriptInterpreter(scriptPath, opts.ScriptShell)
+	if err != nil {
+		return "", false, err
+	}
+
+	key := scriptCacheKey(scriptPath, interpreter, script, env)
+	if ttl > 0 {
+		if stdout, ok := opts.ScriptCache.Get(key, ttl); ok {
+			return stdout, true, nil
+		}
+	}
+
+	stdout, err := runScript(scriptPath, env, opts.ScriptStdin, opts)
+	if err != nil {
+		return "", false, err
+	}
+
+	if ttl > 0 {
+		if err := opts.ScriptCache.Put(key, stdout); err != nil {
+			return "", false, err
+		}
+	}
+	return stdout, false, nil
+}
+
+// scriptKillDelay is the time between interrupting and killing a script that timed out
+const scriptKillDelay = 5 * time.Second
+
+// scriptInterpreter returns the interpreter and its arguments from the shebang line of the script
+//
+// Scripts without a shebang line are run with the shell, /bin/sh when empty.
+func scriptInterpreter(scriptPath string, shell string) (string, []string, error) {
+	file, err := os.Open(scriptPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot open script: %w", err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", nil, fmt.Errorf("cannot read script: %w", err)
+	}
+
+	shebang, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		return shell, nil, nil
+	}
+
+	fields := strings.Fields(shebang)
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("invalid shebang in %s", scriptPath)
+	}
+	return fields[0], fields[1:], nil
+}
+
+// parseScriptEnvOutput parses the output as env
+func parseScriptEnvOutput(out string) (Variables, error) {
+	envMap := make(Variables)
+	scanner := bufio.NewScanner(bytes.NewBufferString(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "=") {
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) == 2 {
+				key := parts[0]
+				value := parts[1]
+				envMap[key] = value
+			}
+		}
+	}
+
+	return envMap, nil
+}
@@ -0,0 +1,162 @@
+package restree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kamil-koziol/restree/pkg/httpparser"
+)
+
+// Trace records where the headers and variables of a request came from
+//
+// A nil *Trace records nothing, so it can be passed around unconditionally.
+type Trace struct {
+	variables  []VariableOrigin
+	headers    httpparser.HTTPHeaders
+	changes    []httpparser.HeaderChange
+	referenced map[string]bool
+}
+
+// VariableOrigin describes an assignment of a variable
+type VariableOrigin struct {
+	Name   string
+	Value  string
+	Source string
+	// Overrides are the sources of the earlier assignments replaced by this one
+	Overrides []string
+}
+
+// HeaderOrigin describes a header of the final request
+type HeaderOrigin struct {
+	Header httpparser.HTTPHeader
+	// Source is the file and line the header came from
+	Source string
+	// Overrides are the sources of the inherited headers replaced by this one
+	Overrides []string
+	// RemovedBy is the source of the -Header directive that removed the header
+	RemovedBy string
+}
+
+var identifierRegexp = regexp.MustCompile(`\w+`)
+
+// AddVariables records the variables assigned from the source
+func (t *Trace) AddVariables(source string, variables Variables) {
+	if t == nil {
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		t.variables = append(t.variables, VariableOrigin{Name: name, Value: variables[name], Source: source})
+	}
+}
+
+// mergeHeaders merges the headers of the closer level, see [httpparser.HTTPHeaders.Merge], recording the changes
+func (t *Trace) mergeHeaders(headers httpparser.HTTPHeaders, other httpparser.HTTPHeaders) httpparser.HTTPHeaders {
+	if t == nil {
+		return headers.Merge(other)
+	}
+	merged, changes := headers.MergeChanges(other)
+	t.headers = merged
+	t.changes = append(t.changes, changes...)
+	return merged
+}
+
+// Reference records the variables referenced by placeholders in the content
+func (t *Trace) Reference(content string) {
+	if t == nil {
+		return
+	}
+	if t.referenced == nil {
+		t.referenced = map[string]bool{}
+	}
+	for _, m := range placeholderRegexp.FindAllStringSubmatch(content, -1) {
+		if !isExpression(m[1]) {
+			continue
+		}
+		for _, name := range identifierRegexp.FindAllString(m[1], -1) {
+			t.referenced[name] = true
+		}
+	}
+}
+
+// Referenced reports whether the variable is referenced by any traced file
+func (t *Trace) Referenced(name string) bool {
+	return t != nil && t.referenced[name]
+}
+
+// Variables returns the final assignment of every variable sorted by name
+func (t *Trace) Variables() []VariableOrigin {
+	if t == nil {
+		return nil
+	}
+
+	final := map[string]*VariableOrigin{}
+	for _, v := range t.variables {
+		origin := v
+		if previous, ok := final[v.Name]; ok {
+			origin.Overrides = append(slices.Clone(previous.Overrides), previous.Source)
+		}
+		final[v.Name] = &origin
+	}
+
+	result := make([]VariableOrigin, 0, len(final))
+	for _, name := range slices.Sorted(maps.Keys(final)) {
+		result = append(result, *final[name])
+	}
+	return result
+}
+
+// Headers returns the headers of the last merge in order and the inherited headers that were removed
+func (t *Trace) Headers() ([]HeaderOrigin, []HeaderOrigin) {
+	if t == nil {
+		return nil, nil
+	}
+
+	active := []HeaderOrigin{}
+	for _, h := range t.headers {
+		origin := HeaderOrigin{Header: h, Source: source(h.File, h.Line)}
+		for _, c := range t.changes {
+			if c.By.Op != httpparser.HeaderUnset && c.By.File == h.File && c.By.Line == h.Line && strings.EqualFold(c.By.Name, h.Name) {
+				origin.Overrides = append(origin.Overrides, source(c.Header.File, c.Header.Line))
+			}
+		}
+		active = append(active, origin)
+	}
+
+	removed := []HeaderOrigin{}
+	for _, c := range t.changes {
+		if c.By.Op == httpparser.HeaderUnset {
+			removed = append(removed, HeaderOrigin{
+				Header:    c.Header,
+				Source:    source(c.Header.File, c.Header.Line),
+				RemovedBy: source(c.By.File, c.By.Line),
+			})
+		}
+	}
+
+	return active, removed
+}
+
+func source(file string, line int) string {
+	if line == 0 {
+		return file
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// referenceReader records the references in r and returns a reader with the same content
+func (t *Trace) referenceReader(r io.Reader) (io.Reader, error) {
+	if t == nil {
+		return r, nil
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	t.Reference(string(b))
+	return bytes.NewReader(b), nil
+}
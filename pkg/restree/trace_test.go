@@ -0,0 +1,68 @@
+package restree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func TestTrace(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".env":                      "host=http://localhost\nunused=1\n",
+		HeadersFileName:             "Authorization: Bearer {{token:-none}}\nAccept: application/json\nX-Client: root\n",
+		"public/_vars.env":          "host=http://public\n",
+		"public/" + HeadersFileName: "-Authorization\n+Accept: text/csv\n",
+		"public/list.http":          "GET {{host}}/public\nX-Client: request\n",
+	})
+
+	trace := &Trace{}
+	trace.AddVariables("process environment", Variables{"host": "http://env"})
+
+	_, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "public", "list.http"), Variables{"host": "http://env"}, RecursiveReadOpts{
+		Trace: trace,
+	})
+	assert.Eq(t, nil, err)
+
+	active, removed := trace.Headers()
+	assert.Eq(t, 3, len(active))
+	assert.Eq(t, "Accept: application/json", active[0].Header.String())
+	assert.Eq(t, HeadersFileName+":2", active[0].Source)
+	assert.Eq(t, "Accept: text/csv", active[1].Header.String())
+	assert.Eq(t, "public/list.http:2", active[2].Source)
+	assert.Eq(t, 1, len(active[2].Overrides))
+	assert.Eq(t, HeadersFileName+":3", active[2].Overrides[0])
+
+	assert.Eq(t, 1, len(removed))
+	assert.Eq(t, "Authorization", removed[0].Header.Name)
+	assert.Eq(t, "public/"+HeadersFileName+":1", removed[0].RemovedBy)
+
+	variables := trace.Variables()
+	assert.Eq(t, 2, len(variables))
+	assert.Eq(t, "host", variables[0].Name)
+	assert.Eq(t, "http://public", variables[0].Value)
+	assert.Eq(t, "public/_vars.env", variables[0].Source)
+	assert.Eq(t, 2, len(variables[0].Overrides))
+	assert.Eq(t, "unused", variables[1].Name)
+
+	assert.Eq(t, true, trace.Referenced("host"))
+	assert.Eq(t, true, trace.Referenced("token"))
+	assert.Eq(t, false, trace.Referenced("unused"))
+}
+
+func TestTraceReferencedSelectedRequest(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"users.http": "GET http://localhost/{{list}}\n\n### create\nPOST http://localhost/{{create}}\n",
+	})
+
+	trace := &Trace{}
+	_, err := RecursiveReadFS(os.DirFS(dir), dir, filepath.Join(dir, "users.http"), Variables{"list": "a", "create": "b"}, RecursiveReadOpts{
+		Request: "create",
+		Trace:   trace,
+	})
+	assert.Eq(t, nil, err)
+
+	assert.Eq(t, true, trace.Referenced("create"))
+	assert.Eq(t, false, trace.Referenced("list"))
+}
@@ -0,0 +1,68 @@
+package restree
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FindHTTPFiles walks dir and returns paths of all request files in lexical order
+//
+// Files and directories starting with "_" (e.g. [HeadersFileName], [EnvDirName]) are not requests and are skipped.
+// When include is not empty, only paths matching one of its patterns are returned.
+// Paths matching any exclude pattern are skipped. See [MatchPath] for the pattern semantics.
+func FindHTTPFiles(fsys fs.FS, dir string, include []string, exclude []string) ([]string, error) {
+	files := []string{}
+
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(d.Name(), "_") || MatchPath(exclude, p)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if path.Ext(p) != ".http" || strings.HasPrefix(d.Name(), "_") {
+			return nil
+		}
+		if len(include) > 0 && !MatchPath(include, p) {
+			return nil
+		}
+		if MatchPath(exclude, p) {
+			return nil
+		}
+
+		files = append(files, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// MatchPath reports whether the slash-separated path p matches any of the patterns
+//
+// A pattern in the [path.Match] syntax matches when it matches the whole path,
+// its base name or any of its parent directories, so "users" matches "users/get.http"
+// and "*.http" matches "users/get.http".
+func MatchPath(patterns []string, p string) bool {
+	candidates := []string{p, path.Base(p)}
+	for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		candidates = append(candidates, dir)
+	}
+
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package restree
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/kamil-koziol/restree/internal/assert"
+)
+
+func validTree() fstest.MapFS {
+	return fstest.MapFS{
+		HeadersFileName:            {},
+		"health.http":              {},
+		"users/" + HeadersFileName: {},
+		"users/get.http":           {},
+		"users/create.http":        {},
+		"users/notes.txt":          {},
+		"admin/delete.http":        {},
+	}
+}
+
+func TestFindHTTPFiles(t *testing.T) {
+	tests := []struct {
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{nil, nil, []string{"admin/delete.http", "health.http", "users/create.http", "users/get.http"}},
+		{[]string{"users"}, nil, []string{"users/create.http", "users/get.http"}},
+		{nil, []string{"admin", "create.http"}, []string{"health.http", "users/get.http"}},
+		{[]string{"users/g*"}, nil, []string{"users/get.http"}},
+	}
+
+	for _, tt := range tests {
+		files, err := FindHTTPFiles(validTree(), ".", tt.include, tt.exclude)
+		assert.Eq(t, nil, err)
+		assert.Eq(t, len(tt.expected), len(files))
+		for i := range files {
+			assert.Eq(t, tt.expected[i], files[i])
+		}
+	}
+}